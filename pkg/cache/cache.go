@@ -0,0 +1,99 @@
+// Package cache provides an on-disk record of file contents that are known to
+// be formatted already, so that yam can skip formatting them again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// dirName is the name of the cache's directory within the user's cache
+// directory.
+const dirName = "yam"
+
+// DefaultDir returns the directory used for the cache by default, which is
+// "yam" within the user's cache directory (e.g. $XDG_CACHE_HOME/yam on Linux).
+func DefaultDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user cache directory: %w", err)
+	}
+
+	return filepath.Join(userCacheDir, dirName), nil
+}
+
+// Cache records which file contents are known to be formatted for a given set
+// of formatting options. Entries are stored as empty files named after a hash
+// of the content, the options and the version of yam, so that the cache is safe
+// to use from multiple goroutines and processes at once.
+type Cache struct {
+	dir     string
+	version string
+}
+
+// New returns a Cache that stores its entries in dir. The version should
+// identify the version of yam, since a different version could format the same
+// content differently.
+func New(dir, version string) *Cache {
+	return &Cache{
+		dir:     dir,
+		version: version,
+	}
+}
+
+// Contains reports whether the content is known to be formatted according to
+// the options, which can be any encoding of the formatting options, as long as
+// it's the same each time.
+func (c *Cache) Contains(content, options []byte) bool {
+	_, err := os.Stat(c.entryPath(content, options))
+	return err == nil
+}
+
+// Add records that the content is formatted according to the options.
+func (c *Cache) Add(content, options []byte) error {
+	p := c.entryPath(content, options)
+
+	err := os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("adding cache entry: %w", err)
+	}
+
+	return f.Close()
+}
+
+// entryPath returns the path of the entry for the content and options. Entries
+// are spread across subdirectories named after the first two characters of
+// their hash, to keep directories small.
+func (c *Cache) entryPath(content, options []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(c.version), options, content} {
+		// Prefix each part with its length, so that the boundaries between parts
+		// can't be confused.
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	key := hex.EncodeToString(h.Sum(nil))
+
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Clean removes the cache directory dir and everything in it. It's not an
+// error if the directory doesn't exist.
+func Clean(dir string) error {
+	err := os.RemoveAll(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing cache directory %q: %w", dir, err)
+	}
+
+	return nil
+}
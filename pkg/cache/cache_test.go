@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "yam")
+	c := New(dir, "v1.0.0")
+
+	content := []byte("a: 1\n")
+	options := []byte(`{"indent":2}`)
+
+	assert.False(t, c.Contains(content, options))
+
+	require.NoError(t, c.Add(content, options))
+	assert.True(t, c.Contains(content, options))
+
+	// Adding the same entry again is fine.
+	require.NoError(t, c.Add(content, options))
+
+	// Entries are specific to the content, the options and the version.
+	assert.False(t, c.Contains([]byte("a: 2\n"), options))
+	assert.False(t, c.Contains(content, []byte(`{"indent":4}`)))
+	assert.False(t, New(dir, "v1.0.1").Contains(content, options))
+
+	require.NoError(t, Clean(dir))
+	assert.False(t, c.Contains(content, options))
+
+	_, err := os.Stat(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Cleaning a cache that doesn't exist is fine.
+	assert.NoError(t, Clean(dir))
+}
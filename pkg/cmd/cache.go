@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+func cacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the cache of files known to be formatted",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clean",
+		Short: "remove everything from the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, err := cache.DefaultDir()
+			if err != nil {
+				return err
+			}
+
+			return cache.Clean(dir)
+		},
+	})
+
+	return cmd
+}
+
+// openCache returns the cache to use for formatting and linting, or nil if
+// there's no cache available.
+func openCache() *cache.Cache {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		// The cache is only an optimization, so do without it.
+		return nil
+	}
+
+	v, ok := version()
+	if !ok {
+		return nil
+	}
+
+	return cache.New(dir, v)
+}
+
+// version identifies the version of yam that's running, for use in cache
+// entries. It returns false if the version can't be determined reliably, e.g.
+// for a build with uncommitted changes.
+func version() (string, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", false
+	}
+
+	var revision string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				return "", false
+			}
+		}
+	}
+
+	// Builds from a module with uncommitted changes can still get a version,
+	// with a "+dirty" suffix.
+	if strings.HasSuffix(info.Main.Version, "+dirty") {
+		return "", false
+	}
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version, true
+	}
+
+	if revision == "" {
+		return "", false
+	}
+
+	return fmt.Sprintf("devel-%s", revision), true
+}
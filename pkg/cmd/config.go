@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chainguard-dev/yam/pkg/util"
+	"github.com/chainguard-dev/yam/pkg/yam"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "work with yam config files",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate [<file>...]",
+		Short: "check config files for unknown settings and invalid values",
+		Long: `Check config files for unknown settings, values of the wrong type, and invalid
+path expressions and glob patterns, and report every problem found. If no files
+are given, the ` + util.ConfigFileName + ` file in the current directory is checked.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: runConfigValidate,
+	})
+
+	printCmd := &cobra.Command{
+		Use:   "print [<file>]",
+		Short: "show the configuration that applies to a file",
+		Long: `Show the configuration yam uses for the given file, or for files in the current
+directory if no file is given, as YAML. Each value is annotated with where it
+came from: a config file, a flag, or yam's defaults. The same formatting flags
+as for formatting files can be given, to see how they combine with config files.
+
+The settings for finding files (recursive, include and exclude) always come
+from the configuration for the current directory.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runConfigPrint,
+	}
+	addConfigFlags(printCmd)
+	cmd.AddCommand(printCmd)
+
+	return cmd
+}
+
+func runConfigValidate(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{util.ConfigFileName}
+	}
+
+	var errs []error
+	for _, p := range args {
+		if _, err := yam.ReadConfigFile(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func runConfigPrint(cmd *cobra.Command, args []string) error {
+	resolver, err := newConfigResolver(cmd, ".")
+	if err != nil {
+		return err
+	}
+
+	dirCfg, err := resolver.forDir(".")
+	if err != nil {
+		return err
+	}
+
+	fileCfg := dirCfg
+	if len(args) == 1 {
+		p, err := relativePath(args[0])
+		if err != nil {
+			return err
+		}
+
+		fileCfg, err = resolver.forFile(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	effective, err := effectiveConfig(fileCfg, dirCfg)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent(2)
+	if err := enc.Encode(effective); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// relativePath returns the given path relative to the current directory, using
+// forward slashes.
+func relativePath(p string) (string, error) {
+	if filepath.IsAbs(p) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("getting working directory: %w", err)
+		}
+
+		p, err = filepath.Rel(wd, p)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.ToSlash(filepath.Clean(p)), nil
+}
+
+// effectiveConfig returns a YAML mapping of every config setting's effective
+// value, with comments saying where each value came from. The formatting
+// settings come from fileCfg, and the settings for finding files come from
+// dirCfg.
+func effectiveConfig(fileCfg, dirCfg yam.FormatConfig) (*yaml.Node, error) {
+	fileOptions := fileCfg.FormatOptions()
+	dirOptions := dirCfg.FormatOptions()
+
+	settings := []struct {
+		name  string
+		cfg   yam.FormatConfig
+		value any
+	}{
+		{"indent", fileCfg, fileOptions.EncodeOptions.Indent},
+		{"gap", fileCfg, fileOptions.EncodeOptions.GapExpressions},
+		{"sort", fileCfg, fileOptions.EncodeOptions.SortExpressions},
+		{"quote", fileCfg, fileOptions.EncodeOptions.QuoteExpressions},
+		{"dedup", fileCfg, fileOptions.EncodeOptions.DedupExpressions},
+		{"final-newline", fileCfg, fileOptions.FinalNewline},
+		{"trim-lines", fileCfg, fileOptions.TrimTrailingWhitespace},
+		{"recursive", dirCfg, dirOptions.DiscoveryOptions.Recursive},
+		{"include", dirCfg, dirOptions.DiscoveryOptions.Include},
+		{"exclude", dirCfg, dirOptions.DiscoveryOptions.Exclude},
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: s.name}
+
+		value := new(yaml.Node)
+		if list, ok := s.value.([]string); ok && len(list) == 0 {
+			value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		} else if err := value.Encode(s.value); err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", s.name, err)
+		}
+
+		if items := s.cfg.ItemSources(s.name); len(items) > 0 && len(items) == len(value.Content) {
+			for i, item := range value.Content {
+				item.LineComment = describeSource(s.name, items[i], true)
+			}
+		} else {
+			source, ok := s.cfg.Source(s.name)
+			value.LineComment = describeSource(s.name, source, ok)
+		}
+
+		root.Content = append(root.Content, key, value)
+	}
+
+	return root, nil
+}
+
+// describeSource describes where the value of a setting came from, for
+// annotating printed configuration.
+func describeSource(setting, source string, set bool) string {
+	switch {
+	case !set:
+		return "default"
+	case source == flagSource:
+		return "flag --" + setting
+	default:
+		return source
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/util"
+	"github.com/chainguard-dev/yam/pkg/yam"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	flagForce  = "force"
+	flagStdout = "stdout"
+)
+
+func initCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init [<file>...]",
+		Short: "create a config file that matches the style of existing YAML files",
+		Long: `Analyze the existing YAML files, and write a ` + util.ConfigFileName + ` file to the current
+directory with the settings that reproduce their style as closely as possible:
+the indent, and the gap, sort and quote expressions for the paths that
+consistently use them. Directories are searched recursively, skipping files
+listed in .gitignore and .yamignore files. If no files are given, the current
+directory is analyzed.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: runInit,
+	}
+
+	cmd.Flags().Bool(flagForce, false, "overwrite an existing config file")
+	cmd.Flags().Bool(flagStdout, false, "print the config instead of writing it to a file")
+
+	return cmd
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool(flagForce)
+	toStdout, _ := cmd.Flags().GetBool(flagStdout)
+
+	if !force && !toStdout {
+		if _, err := os.Stat(util.ConfigFileName); err == nil {
+			return fmt.Errorf("%s already exists, use --%s to overwrite it", util.ConfigFileName, flagForce)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	// Config files, including the one that's being replaced, don't show the
+	// style of the project's YAML files.
+	discovery := yam.DiscoveryOptions{
+		Recursive: true,
+		Exclude:   []string{"**/" + util.ConfigFileName},
+	}
+
+	fsys := os.DirFS(".")
+	cfg, files, err := yam.InferConfig(fsys, args, discovery)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no YAML files found to analyze")
+	}
+
+	// Make formatting without arguments pick up the files that were analyzed.
+	for _, f := range files {
+		if strings.Contains(f, "/") {
+			recursive := true
+			cfg.Recursive = &recursive
+			break
+		}
+	}
+
+	b, err := encodeConfig(cfg, len(files))
+	if err != nil {
+		return err
+	}
+
+	if toStdout {
+		_, err := cmd.OutOrStdout().Write(b)
+		return err
+	}
+
+	if err := os.WriteFile(util.ConfigFileName, b, 0o644); err != nil {
+		return err
+	}
+
+	// Tell the user how close the config gets to the existing style, along with
+	// any other config files that apply to the files.
+	loader, err := yam.NewConfigLoader(".", "")
+	if err != nil {
+		return err
+	}
+
+	options := cfg.FormatOptions()
+	options.OptionsForFile = func(p string) (yam.FormatOptions, error) {
+		fileCfg, err := loader.ConfigForFile(p)
+		if err != nil {
+			return yam.FormatOptions{}, err
+		}
+
+		return fileCfg.FormatOptions(), nil
+	}
+
+	results, err := yam.LintFiles(fsys, files, options)
+	if err != nil {
+		var fileErrs yam.FileErrors
+		if !errors.As(err, &fileErrs) {
+			return err
+		}
+	}
+
+	var passed int
+	for _, r := range results {
+		if r.Passed() {
+			passed++
+		}
+	}
+
+	verb := "match"
+	if passed == 1 {
+		verb = "matches"
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "wrote %s; %d of %d %s already %s it\n", util.ConfigFileName, passed, len(files), plural(len(files), "file"), verb)
+
+	return nil
+}
+
+// encodeConfig encodes the config as the content of a config file.
+func encodeConfig(cfg yam.FormatConfig, fileCount int) ([]byte, error) {
+	n := new(yaml.Node)
+	if err := n.Encode(cfg); err != nil {
+		return nil, err
+	}
+	n.HeadComment = fmt.Sprintf("Generated by yam init from %d YAML %s.", fileCount, plural(fileCount, "file"))
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// plural returns the plural of the given noun if n isn't 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+
+	return noun + "s"
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/yam/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit_force(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.WriteFile("a.yaml", []byte("a:\n    b: 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(util.ConfigFileName, []byte("indent: 2\n"), 0o644))
+
+	stderr := new(bytes.Buffer)
+	cmd := Root()
+	cmd.SetArgs([]string{"init", "--force"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(stderr)
+	require.NoError(t, cmd.Execute())
+
+	// The config file that's replaced isn't analyzed.
+	content, err := os.ReadFile(util.ConfigFileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "from 1 YAML file.")
+	assert.Contains(t, string(content), "indent: 4")
+
+	assert.Equal(t, "wrote .yam.yaml; 1 of 1 file already matches it\n", stderr.String())
+}
+
+func TestInit_nestedConfigs(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.Mkdir("sub", 0o755))
+	require.NoError(t, os.WriteFile("a.yaml", []byte("a:\n    b: 1\n"), 0o644))
+	require.NoError(t, os.WriteFile("c.yaml", []byte("c:\n    d: 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join("sub", "b.yaml"), []byte("b:\n  c: 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join("sub", util.ConfigFileName), []byte("indent: 2\n"), 0o644))
+
+	stderr := new(bytes.Buffer)
+	cmd := Root()
+	cmd.SetArgs([]string{"init"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(stderr)
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(util.ConfigFileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "from 3 YAML files.")
+	assert.Contains(t, string(content), "indent: 4")
+
+	// The nested config applies to the file in its directory.
+	assert.Equal(t, "wrote .yam.yaml; 3 of 3 files already match it\n", stderr.String())
+}
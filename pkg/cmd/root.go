@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 
 	osAdapter "github.com/chainguard-dev/yam/pkg/rwfs/os"
-	"github.com/chainguard-dev/yam/pkg/util"
 	"github.com/chainguard-dev/yam/pkg/yam"
-	"github.com/chainguard-dev/yam/pkg/yam/formatted"
+	"github.com/chainguard-dev/yam/pkg/yam/report"
 	"github.com/spf13/cobra"
 )
 
@@ -22,44 +25,133 @@ const (
 	flagConfig       = "config"
 	flagQuote        = "quote"
 	flagDedup        = "dedup"
+	flagRecursive    = "recursive"
+	flagInclude      = "include"
+	flagExclude      = "exclude"
+	flagNoIgnore     = "no-ignore"
+	flagStdin        = "stdin"
+	flagStdinName    = "stdin-filename"
+	flagOutputFormat = "output-format"
+	flagListChanged  = "list-changed"
+	flagJobs         = "jobs"
+	flagFailFast     = "fail-fast"
+	flagNoCache      = "no-cache"
 )
 
+// stdinArg is the file argument that means "read from stdin".
+const stdinArg = "-"
+
 func Root() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:           "yam <file>...",
+		Use:           "yam [<file>... | -]",
 		Short:         "format YAML files",
+		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
-	cmd.Flags().Int(flagIndent, 2, "number of spaces used to indent a line")
-	cmd.Flags().StringSlice(flagGap, nil, "YAML path expression to a mapping or sequence node whose children should be separated by empty lines")
-	cmd.Flags().StringSlice(flagSort, nil, "YAML path expression to a mapping or sequence node whose children should be sorted")
-	cmd.Flags().Bool(flagFinalNewline, true, "ensure file ends with a final newline character")
-	cmd.Flags().Bool(flagTrimLines, true, "trim any trailing spaces from each line")
+	addConfigFlags(cmd)
 	cmd.Flags().Bool(flagLint, false, "don't modify files, but exit 1 if files aren't formatted")
-	cmd.Flags().StringP(flagConfig, "c", "", "path to a yam configuration YAML file")
-	cmd.Flags().StringSlice(flagQuote, nil, "YAML path expression to a node that should be quoted")
-	cmd.Flags().StringSlice(flagDedup, nil, "YAML path expression to a sequence node whose children should be deduplicated")
+	cmd.Flags().Bool(flagNoIgnore, false, "don't skip files and directories listed in .gitignore and .yamignore files")
+	cmd.Flags().Bool(flagStdin, false, "read YAML from stdin and write the formatted result to stdout (same as passing '-' as the only file)")
+	cmd.Flags().String(flagStdinName, "", "path to treat stdin input as having, so that ignore files and include/exclude patterns apply to it")
+	cmd.Flags().IntP(flagJobs, "j", runtime.GOMAXPROCS(0), "number of files to process at once")
+	cmd.Flags().Bool(flagFailFast, false, "stop at the first file that can't be formatted or linted, rather than processing the rest and reporting every failure")
+	cmd.Flags().Bool(flagNoCache, false, "don't use or update the cache of files known to be formatted")
+	cmd.Flags().Bool(flagListChanged, false, "print the path of each file whose content was changed by formatting")
+	cmd.Flags().String(flagOutputFormat, string(report.FormatText), fmt.Sprintf("format for lint results, one of %v", report.Formats))
 
 	cmd.RunE = runRoot
 
+	// Subcommands share their namespace with file arguments, so keep it small.
+	// A path with a subcommand's name can still be given as e.g. "./config".
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.AddCommand(cacheCmd())
+	cmd.AddCommand(configCmd())
+	cmd.AddCommand(initCmd())
+
 	return cmd
 }
 
+// addConfigFlags adds the flags for the settings that can also be set in config
+// files, along with the flag for choosing a config file.
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().Int(flagIndent, 2, "number of spaces used to indent a line")
+	cmd.Flags().StringArray(flagGap, nil, "YAML path expression to a mapping or sequence node whose children should be separated by empty lines")
+	cmd.Flags().StringArray(flagSort, nil, "YAML path expression to a mapping or sequence node whose children should be sorted")
+	cmd.Flags().Bool(flagFinalNewline, true, "ensure file ends with a final newline character")
+	cmd.Flags().Bool(flagTrimLines, true, "trim any trailing spaces from each line")
+	cmd.Flags().StringP(flagConfig, "c", "", "path to a yam configuration YAML file")
+	cmd.Flags().StringArray(flagQuote, nil, "YAML path expression to a node that should be quoted")
+	cmd.Flags().StringArray(flagDedup, nil, "YAML path expression to a sequence node whose children should be deduplicated")
+	cmd.Flags().BoolP(flagRecursive, "r", false, "look for YAML files in subdirectories of given directories, too")
+	cmd.Flags().StringSlice(flagInclude, nil, "glob pattern (e.g. '**/*.yaml') for files to process within directories; if set, other files are skipped")
+	cmd.Flags().StringSlice(flagExclude, nil, "glob pattern for files and directories to skip within directories")
+}
+
 func runRoot(cmd *cobra.Command, args []string) error {
-	encoderConfig, err := getConfig(cmd)
+	formatOptions, err := computeFormatOptions(cmd, ".")
 	if err != nil {
 		return err
 	}
+	if formatOptions.Jobs < 1 {
+		return fmt.Errorf("--%s must be at least 1", flagJobs)
+	}
+	if indent, _ := cmd.Flags().GetInt(flagIndent); indent < yam.MinIndent || indent > yam.MaxIndent {
+		return fmt.Errorf("--%s must be between %d and %d", flagIndent, yam.MinIndent, yam.MaxIndent)
+	}
 
-	formatOptions := computeFormatOptions(encoderConfig, cmd)
 	doLint, _ := cmd.Flags().GetBool(flagLint)
 
+	outputFormatName, _ := cmd.Flags().GetString(flagOutputFormat)
+	outputFormat, err := report.ParseFormat(outputFormatName)
+	if err != nil {
+		return err
+	}
+	if outputFormat != report.FormatText && !doLint {
+		return fmt.Errorf("--%s can only be used with --%s", flagOutputFormat, flagLint)
+	}
+
+	listChanged, _ := cmd.Flags().GetBool(flagListChanged)
+	if listChanged && doLint {
+		return fmt.Errorf("--%s can't be used with --%s", flagListChanged, flagLint)
+	}
+
+	useStdin, _ := cmd.Flags().GetBool(flagStdin)
+	if slices.Contains(args, stdinArg) {
+		if len(args) > 1 {
+			return fmt.Errorf("%q can't be used with other file arguments", stdinArg)
+		}
+		useStdin = true
+		args = nil
+	}
+
+	if useStdin {
+		if len(args) > 0 {
+			return errors.New("file arguments can't be used when reading from stdin")
+		}
+
+		if listChanged {
+			return fmt.Errorf("--%s can't be used when reading from stdin", flagListChanged)
+		}
+
+		return runStdin(cmd, formatOptions, doLint, outputFormat)
+	}
+
 	if doLint {
 		fsys := os.DirFS(".")
 
-		err = yam.Lint(fsys, args, yam.ExecDiff, formatOptions)
+		if outputFormat != report.FormatText {
+			results, err := yam.LintFiles(fsys, args, formatOptions)
+			var fileErr *yam.FileError
+			if err != nil && !errors.As(err, &fileErr) {
+				return err
+			}
+
+			return errors.Join(writeReport(cmd, outputFormat, results), err)
+		}
+
+		err = yam.Lint(fsys, args, yam.UnifiedDiff, formatOptions)
 		if err != nil {
 			return err
 		}
@@ -68,103 +160,296 @@ func runRoot(cmd *cobra.Command, args []string) error {
 	}
 
 	fsys := osAdapter.DirFS(".")
-	err = yam.Format(fsys, args, formatOptions)
-	if err != nil {
-		return err
+	results, err := yam.FormatFiles(fsys, args, formatOptions)
+
+	// Files can still have been changed when other files failed.
+	if listChanged {
+		for _, r := range results {
+			if r.Changed {
+				fmt.Fprintln(cmd.OutOrStdout(), r.Path)
+			}
+		}
 	}
 
-	return nil
+	return err
 }
 
-func getConfig(cmd *cobra.Command) (*formatted.EncodeOptions, error) {
-	var r io.Reader
-	if v, _ := cmd.Flags().GetString(flagConfig); v != "" {
-		f, err := os.Open(v)
+// runStdin formats (or lints) the YAML read from stdin, writing the formatted
+// result to stdout.
+func runStdin(cmd *cobra.Command, formatOptions yam.FormatOptions, doLint bool, outputFormat report.Format) error {
+	name, _ := cmd.Flags().GetString(flagStdinName)
+	dir, name, err := stdinPath(name)
+	if err != nil {
+		return err
+	}
+
+	if dir != "." {
+		// The config and ignore files that apply are found from the file's own
+		// directory, rather than the current one.
+		formatOptions, err = computeFormatOptions(cmd, dir)
 		if err != nil {
-			return nil, fmt.Errorf("opening configuration file: %w", err)
+			return err
 		}
-		r = f
-	} else {
-		f, err := os.Open(util.ConfigFileName)
-		if err != nil {
-			// This is a default best-effort attempt, no need to bubble up the error.
-			return nil, nil
+	}
+
+	fsys := os.DirFS(dir)
+
+	if doLint {
+		if outputFormat != report.FormatText {
+			results, err := yam.LintReaderResults(fsys, name, cmd.InOrStdin(), formatOptions)
+			if err != nil {
+				return err
+			}
+
+			return writeReport(cmd, outputFormat, results)
 		}
-		r = f
+
+		return yam.LintReader(fsys, name, cmd.InOrStdin(), yam.UnifiedDiff, formatOptions)
 	}
 
-	if r == nil {
-		return nil, nil
+	return yam.FormatReader(fsys, name, cmd.InOrStdin(), cmd.OutOrStdout(), formatOptions)
+}
+
+// writeReport writes a report of the lint results to stdout, and returns
+// yam.ErrDidNotPassLintCheck if any file failed the lint check.
+func writeReport(cmd *cobra.Command, format report.Format, results []yam.LintResult) error {
+	err := report.Write(cmd.OutOrStdout(), format, results)
+	if err != nil {
+		return fmt.Errorf("writing %s report: %w", format, err)
 	}
 
-	cfg, err := formatted.ReadConfigFrom(r)
+	for _, r := range results {
+		if !r.Passed() {
+			return yam.ErrDidNotPassLintCheck
+		}
+	}
+
+	return nil
+}
+
+// stdinPath returns the directory that the config and ignore files for the given
+// --stdin-filename value are found from, and the path of the file relative to
+// it, using forward slashes. That's the current working directory, unless the
+// file is outside of it, in which case it's the file's own directory.
+func stdinPath(name string) (string, string, error) {
+	if name == "" {
+		return ".", "", nil
+	}
+
+	wd, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("reading configuration: %w", err)
+		return "", "", fmt.Errorf("getting working directory: %w", err)
+	}
+
+	abs := name
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(wd, abs)
 	}
-	return cfg, nil
+
+	if rel, err := filepath.Rel(wd, abs); err == nil {
+		rel = filepath.ToSlash(rel)
+		if fs.ValidPath(rel) && rel != "." {
+			return ".", rel, nil
+		}
+	}
+
+	return filepath.Dir(abs), filepath.Base(abs), nil
 }
 
-// computeFormatOptions produces a new yam.FormatOptions using an optional
-// provided config (unmarshalled from a file) and flags from a Cobra command.
-// CLI flag values take priority over config file values, which take priority
-// over default values.
-func computeFormatOptions(cfg *formatted.EncodeOptions, cmd *cobra.Command) yam.FormatOptions {
+// flagSource is the source recorded for config values that come from flags.
+const flagSource = "flags"
+
+// configFromFlags returns a config with the values of the formatting flags that
+// were set on the command line.
+func configFromFlags(cmd *cobra.Command) yam.FormatConfig {
 	flags := cmd.Flags()
+	cfg := yam.FormatConfig{}
+
+	if flags.Changed(flagIndent) {
+		indent, _ := flags.GetInt(flagIndent)
+		cfg.Indent = &indent
+	}
 
-	var indent = 2
-	if flag := flags.Lookup(flagIndent); flag.Changed {
-		indent, _ = flags.GetInt(flagIndent)
-	} else if cfg != nil {
-		indent = cfg.Indent
+	if flags.Changed(flagFinalNewline) {
+		finalNewline, _ := flags.GetBool(flagFinalNewline)
+		cfg.FinalNewline = &finalNewline
 	}
 
-	var gapExpressions []string
-	if flag := flags.Lookup(flagGap); flag.Changed {
-		gapExpressions, _ = flags.GetStringSlice(flagGap)
-	} else if cfg != nil {
-		gapExpressions = cfg.GapExpressions
+	if flags.Changed(flagTrimLines) {
+		trimLines, _ := flags.GetBool(flagTrimLines)
+		cfg.TrimLines = &trimLines
 	}
 
-	var sortExpressions []string
-	if flag := flags.Lookup(flagSort); flag.Changed {
-		sortExpressions, _ = flags.GetStringSlice(flagSort)
-	} else if cfg != nil {
-		sortExpressions = cfg.SortExpressions
+	if flags.Changed(flagRecursive) {
+		recursive, _ := flags.GetBool(flagRecursive)
+		cfg.Recursive = &recursive
 	}
 
-	var quoteExpressions []string
-	if flag := flags.Lookup(flagQuote); flag.Changed {
-		quoteExpressions, _ = flags.GetStringSlice(flagQuote)
-	} else if cfg != nil {
-		quoteExpressions = cfg.QuoteExpressions
+	// Path expressions can have commas and quotes in them, so their flags are
+	// string arrays, which are split on commas by splitExpressions rather than
+	// like string slices.
+	expressions := map[string]*[]string{
+		flagGap:   &cfg.Gap,
+		flagSort:  &cfg.Sort,
+		flagQuote: &cfg.Quote,
+		flagDedup: &cfg.Dedup,
 	}
+	for name, list := range expressions {
+		if flags.Changed(name) {
+			values, _ := flags.GetStringArray(name)
 
-	var dedupExpressions []string
-	if flag := flags.Lookup(flagDedup); flag.Changed {
-		dedupExpressions, _ = flags.GetStringSlice(flagDedup)
-	} else if cfg != nil {
-		dedupExpressions = cfg.DedupExpressions
+			// A flag that's set replaces the config's list, even if it's empty.
+			*list = []string{}
+			for _, v := range values {
+				*list = append(*list, splitExpressions(v)...)
+			}
+		}
+	}
+
+	patterns := map[string]*[]string{
+		flagInclude: &cfg.Include,
+		flagExclude: &cfg.Exclude,
+	}
+	for name, list := range patterns {
+		if flags.Changed(name) {
+			values, _ := flags.GetStringSlice(name)
+			*list = append([]string{}, values...)
+		}
 	}
 
-	var finalNewline = true
-	if flag := flags.Lookup(flagFinalNewline); flag.Changed {
-		finalNewline, _ = flags.GetBool(flagFinalNewline)
+	return cfg.WithSource(flagSource)
+}
+
+// splitExpressions splits a flag value into the path expressions separated by
+// commas in it. Commas in quotes, brackets, braces, parentheses and regular
+// expressions are part of an expression, e.g. in ".{a,b}" or ".a[x="y,z"]".
+func splitExpressions(value string) []string {
+	if value == "" {
+		return nil
 	}
 
-	var trimLines = true
-	if flag := flags.Lookup(flagTrimLines); flag.Changed {
-		trimLines, _ = flags.GetBool(flagTrimLines)
+	var (
+		expressions []string
+		start       int
+		depth       int
+		closing     byte // the quote or slash that ends the current string, if any
+	)
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		if closing != 0 {
+			switch c {
+			case '\\':
+				i++
+			case closing:
+				closing = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			closing = '"'
+		case c == '/' && i > 0 && value[i-1] == '.':
+			closing = '/'
+		case c == '[' || c == '{' || c == '(':
+			depth++
+		case c == ']' || c == '}' || c == ')':
+			depth--
+		case c == ',' && depth <= 0:
+			expressions = append(expressions, value[start:i])
+			start = i + 1
+		}
 	}
 
-	return yam.FormatOptions{
-		EncodeOptions: formatted.EncodeOptions{
-			Indent:           indent,
-			GapExpressions:   gapExpressions,
-			SortExpressions:  sortExpressions,
-			QuoteExpressions: quoteExpressions,
-			DedupExpressions: dedupExpressions,
-		},
-		FinalNewline:           finalNewline,
-		TrimTrailingWhitespace: trimLines,
+	return append(expressions, value[start:])
+}
+
+// configResolver finds the config for files, from the config files that apply
+// and the flags from a Cobra command. CLI flag values take priority over config
+// file values.
+//
+// If the --config flag is set, that config file is used for every file.
+// Otherwise, each file uses the .yam.yaml files in its directory and the
+// directories above it, along with any of their overrides that match the file.
+type configResolver struct {
+	loader *yam.ConfigLoader
+	flags  yam.FormatConfig
+}
+
+// newConfigResolver returns a resolver for the files in dir, whose paths are
+// relative to dir.
+func newConfigResolver(cmd *cobra.Command, dir string) (*configResolver, error) {
+	configFile, _ := cmd.Flags().GetString(flagConfig)
+	loader, err := yam.NewConfigLoader(dir, configFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration: %w", err)
 	}
+
+	return &configResolver{
+		loader: loader,
+		flags:  configFromFlags(cmd),
+	}, nil
+}
+
+// forDir returns the config for the given directory, without any overrides.
+func (r *configResolver) forDir(dir string) (yam.FormatConfig, error) {
+	cfg, err := r.loader.ConfigForDir(dir)
+	if err != nil {
+		return yam.FormatConfig{}, fmt.Errorf("reading configuration: %w", err)
+	}
+
+	return cfg.Override(r.flags), nil
+}
+
+// forFile returns the config for the file at the given path, which uses forward
+// slashes and is relative to the current directory.
+func (r *configResolver) forFile(p string) (yam.FormatConfig, error) {
+	cfg, err := r.loader.ConfigForFile(p)
+	if err != nil {
+		return yam.FormatConfig{}, err
+	}
+
+	return cfg.Override(r.flags), nil
+}
+
+// computeFormatOptions produces a new yam.FormatOptions using the config files
+// that apply and flags from a Cobra command, as described by configResolver.
+// Default values are used for anything that neither sets. Options for finding
+// files come from the config that applies to dir, which file paths are relative
+// to; that's usually the current directory.
+func computeFormatOptions(cmd *cobra.Command, dir string) (yam.FormatOptions, error) {
+	flags := cmd.Flags()
+
+	resolver, err := newConfigResolver(cmd, dir)
+	if err != nil {
+		return yam.FormatOptions{}, err
+	}
+
+	cfg, err := resolver.forDir(".")
+	if err != nil {
+		return yam.FormatOptions{}, err
+	}
+
+	options := cfg.FormatOptions()
+	options.OptionsForFile = func(p string) (yam.FormatOptions, error) {
+		cfg, err := resolver.forFile(p)
+		if err != nil {
+			return yam.FormatOptions{}, err
+		}
+
+		return cfg.FormatOptions(), nil
+	}
+
+	options.DiscoveryOptions.NoIgnore, _ = flags.GetBool(flagNoIgnore)
+	options.Jobs, _ = flags.GetInt(flagJobs)
+	options.FailFast, _ = flags.GetBool(flagFailFast)
+
+	if noCache, _ := flags.GetBool(flagNoCache); !noCache {
+		if c := openCache(); c != nil {
+			options.Cache = c
+		}
+	}
+
+	return options, nil
 }
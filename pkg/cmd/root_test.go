@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitExpressions(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "", expected: nil},
+		{value: ".a", expected: []string{".a"}},
+		{value: ".a,.b[0]", expected: []string{".a", ".b[0]"}},
+		{value: `.metadata.labels."a,b"`, expected: []string{`.metadata.labels."a,b"`}},
+		{value: `."a\",b",.c`, expected: []string{`."a\",b"`, ".c"}},
+		{value: `.["a,b"],.c`, expected: []string{`.["a,b"]`, ".c"}},
+		{value: ".{a,b}.c,.d", expected: []string{".{a,b}.c", ".d"}},
+		{value: `.steps[name="a,b"]`, expected: []string{`.steps[name="a,b"]`}},
+		{value: `.steps[] | select(.uses == "a,b"),.c`, expected: []string{`.steps[] | select(.uses == "a,b")`, ".c"}},
+		{value: "./a,b/.c,.d", expected: []string{"./a,b/.c", ".d"}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitExpressions(tt.value))
+		})
+	}
+}
+
+func TestRunStdin_outsideWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "proj"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "other"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "proj", ".yam.yaml"), []byte("indent: 4\n"), 0o644))
+	t.Chdir(filepath.Join(dir, "other"))
+
+	for _, name := range []string{filepath.Join(dir, "proj", "x.yaml"), "../proj/x.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			cmd := Root()
+			cmd.SetArgs([]string{"--stdin", "--stdin-filename", name})
+			cmd.SetIn(strings.NewReader("a:\n  b: 1\n"))
+			cmd.SetOut(stdout)
+			require.NoError(t, cmd.Execute())
+
+			assert.Equal(t, "a:\n    b: 1\n", stdout.String())
+		})
+	}
+}
+
+func TestRunRoot_invalidIndent(t *testing.T) {
+	cmd := Root()
+	cmd.SetArgs([]string{"--indent=-1", "--stdin"})
+	cmd.SetIn(strings.NewReader("a: 1\n"))
+	cmd.SetOut(new(bytes.Buffer))
+
+	assert.EqualError(t, cmd.Execute(), "--indent must be between 2 and 9")
+}
@@ -0,0 +1,281 @@
+// Package diff computes line-based differences between two texts and renders
+// them in the unified diff format.
+package diff
+
+import (
+	"bytes"
+)
+
+// OpKind describes what happened to a line between the old and new text.
+type OpKind int
+
+const (
+	// Equal means the line is present in both texts.
+	Equal OpKind = iota
+
+	// Delete means the line is only present in the old text.
+	Delete
+
+	// Insert means the line is only present in the new text.
+	Insert
+)
+
+// Line is a single line of a diff.
+type Line struct {
+	Kind OpKind
+
+	// Text is the line's content, including its trailing newline, if it has one.
+	Text string
+}
+
+// Hunk is a group of nearby changed lines, along with the unchanged lines
+// around them.
+type Hunk struct {
+	// OldStart is the 1-based number of the hunk's first line in the old text.
+	// If the hunk has no lines from the old text, it's the number of the line
+	// after which the hunk's lines were inserted.
+	OldStart int
+
+	// OldLines is the number of lines from the old text in the hunk.
+	OldLines int
+
+	// NewStart is the 1-based number of the hunk's first line in the new text.
+	// If the hunk has no lines from the new text, it's the number of the line
+	// after which the hunk's lines were deleted.
+	NewStart int
+
+	// NewLines is the number of lines from the new text in the hunk.
+	NewLines int
+
+	Lines []Line
+}
+
+// SplitLines splits the given text into lines, each of which keeps its
+// trailing newline. The final line has no newline if the text doesn't end with
+// one.
+func SplitLines(b []byte) []string {
+	var lines []string
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+
+	return lines
+}
+
+// maxEditDistance is the largest number of deletions and insertions that Lines
+// looks for the shortest sequence of. The memory it needs grows with the square
+// of this number.
+const maxEditDistance = 2000
+
+// Lines returns the sequence of equal, deleted and inserted lines that turns
+// the old lines into the new lines, using the shortest possible number of
+// deletions and insertions. If that's more than maxEditDistance, every line
+// between the lines that the texts start and end with in common is deleted and
+// inserted instead.
+func Lines(oldLines, newLines []string) []Line {
+	// The lines the texts start and end with in common don't need to be diffed.
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+
+	var lines []Line
+	for _, l := range oldLines[:prefix] {
+		lines = append(lines, Line{Kind: Equal, Text: l})
+	}
+
+	if trace, ok := shortestEditTrace(a, b, maxEditDistance); ok {
+		lines = append(lines, backtrack(trace, a, b)...)
+	} else {
+		for _, l := range a {
+			lines = append(lines, Line{Kind: Delete, Text: l})
+		}
+		for _, l := range b {
+			lines = append(lines, Line{Kind: Insert, Text: l})
+		}
+	}
+
+	for _, l := range oldLines[len(oldLines)-suffix:] {
+		lines = append(lines, Line{Kind: Equal, Text: l})
+	}
+
+	return lines
+}
+
+// Hunks returns the hunks describing the changes from the old text to the new
+// text, where each hunk includes up to the given number of unchanged lines of
+// context around its changes. It returns nil if the texts are equal.
+func Hunks(oldText, newText []byte, context int) []Hunk {
+	lines := Lines(SplitLines(oldText), SplitLines(newText))
+
+	// Record the 1-based position in the old and new texts of each line. For
+	// lines that are missing from one of the texts, the position is that of the
+	// next line that is present.
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+	oldPos[0], newPos[0] = 1, 1
+	for i, l := range lines {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if l.Kind != Insert {
+			oldPos[i+1]++
+		}
+		if l.Kind != Delete {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		// Find the end of this group of changes, which continues for as long as
+		// the next change is close enough that the context around them would
+		// overlap.
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Kind != Equal {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+
+		start := max(i-context, 0)
+		stop := min(end+context, len(lines))
+
+		h := Hunk{
+			OldStart: oldPos[start],
+			OldLines: oldPos[stop] - oldPos[start],
+			NewStart: newPos[start],
+			NewLines: newPos[stop] - newPos[start],
+			Lines:    lines[start:stop],
+		}
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+
+		hunks = append(hunks, h)
+		i = stop
+	}
+
+	return hunks
+}
+
+// shortestEditTrace runs the Myers diff algorithm on the given lines, and
+// returns a copy of the algorithm's state (the furthest reaching endpoint of
+// each diagonal) before each round of edits. See "An O(ND) Difference
+// Algorithm and Its Variations" by Eugene W. Myers.
+//
+// The copy for round d only has the diagonals -d-1 to d+1, which are the only
+// ones backtracking through that round needs, starting with diagonal -d-1. It
+// returns false if more than limit edits are needed.
+func shortestEditTrace(a, b []string, limit int) ([][]int, bool) {
+	n, m := len(a), len(b)
+	maxEdits := n + m
+	offset := maxEdits + 1
+
+	v := make([]int, 2*maxEdits+3)
+	var trace [][]int
+
+	for d := 0; d <= maxEdits; d++ {
+		if d > limit {
+			return nil, false
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // move down (insertion)
+			} else {
+				x = v[offset+k-1] + 1 // move right (deletion)
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return trace, true
+			}
+		}
+	}
+
+	return trace, true
+}
+
+// backtrack walks back through the trace from shortestEditTrace to recover the
+// edits that were made.
+func backtrack(trace [][]int, a, b []string) []Line {
+	x, y := len(a), len(b)
+
+	var reversed []Line
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// The state for round d starts with diagonal -d-1.
+		v := trace[d]
+		offset := d + 1
+
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Line{Kind: Equal, Text: a[x]})
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if x == prevX {
+			y--
+			reversed = append(reversed, Line{Kind: Insert, Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, Line{Kind: Delete, Text: a[x]})
+		}
+	}
+
+	lines := make([]Line, len(reversed))
+	for i, l := range reversed {
+		lines[len(reversed)-1-i] = l
+	}
+
+	return lines
+}
@@ -0,0 +1,198 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHunks(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new string
+		context  int
+		expected []Hunk
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+		},
+		{
+			name:    "changed line",
+			old:     "a\nb\nc\n",
+			new:     "a\nB\nc\n",
+			context: 1,
+			expected: []Hunk{
+				{
+					OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+					Lines: []Line{
+						{Kind: Equal, Text: "a\n"},
+						{Kind: Delete, Text: "b\n"},
+						{Kind: Insert, Text: "B\n"},
+						{Kind: Equal, Text: "c\n"},
+					},
+				},
+			},
+		},
+		{
+			name: "insertion without context",
+			old:  "a\nb\n",
+			new:  "a\nx\nb\n",
+			expected: []Hunk{
+				{
+					OldStart: 1, OldLines: 0, NewStart: 2, NewLines: 1,
+					Lines: []Line{
+						{Kind: Insert, Text: "x\n"},
+					},
+				},
+			},
+		},
+		{
+			name:    "distant changes produce separate hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n",
+			new:     "one\n2\n3\n4\n5\n6\nseven\n",
+			context: 1,
+			expected: []Hunk{
+				{
+					OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+					Lines: []Line{
+						{Kind: Delete, Text: "1\n"},
+						{Kind: Insert, Text: "one\n"},
+						{Kind: Equal, Text: "2\n"},
+					},
+				},
+				{
+					OldStart: 6, OldLines: 2, NewStart: 6, NewLines: 2,
+					Lines: []Line{
+						{Kind: Equal, Text: "6\n"},
+						{Kind: Delete, Text: "7\n"},
+						{Kind: Insert, Text: "seven\n"},
+					},
+				},
+			},
+		},
+		{
+			name:    "nearby changes share a hunk",
+			old:     "1\n2\n3\n4\n",
+			new:     "one\n2\n3\nfour\n",
+			context: 1,
+			expected: []Hunk{
+				{
+					OldStart: 1, OldLines: 4, NewStart: 1, NewLines: 4,
+					Lines: []Line{
+						{Kind: Delete, Text: "1\n"},
+						{Kind: Insert, Text: "one\n"},
+						{Kind: Equal, Text: "2\n"},
+						{Kind: Equal, Text: "3\n"},
+						{Kind: Delete, Text: "4\n"},
+						{Kind: Insert, Text: "four\n"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hunks([]byte(tt.old), []byte(tt.new), tt.context)
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("unexpected hunks (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	numbered := func(prefix string, n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%s%d\n", prefix, i)
+		}
+		return lines
+	}
+
+	// apply returns the old and new lines described by a diff.
+	apply := func(lines []Line) (oldLines, newLines []string) {
+		for _, l := range lines {
+			if l.Kind != Insert {
+				oldLines = append(oldLines, l.Text)
+			}
+			if l.Kind != Delete {
+				newLines = append(newLines, l.Text)
+			}
+		}
+		return oldLines, newLines
+	}
+
+	edits := func(lines []Line) int {
+		var n int
+		for _, l := range lines {
+			if l.Kind != Equal {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("large files with a few changes", func(t *testing.T) {
+		oldLines := numbered("line ", 5000)
+		newLines := slices.Clone(oldLines)
+		for i := 0; i < len(newLines); i += 500 {
+			newLines[i] = "changed\n"
+		}
+
+		lines := Lines(oldLines, newLines)
+
+		gotOld, gotNew := apply(lines)
+		require.Equal(t, oldLines, gotOld)
+		require.Equal(t, newLines, gotNew)
+		require.Equal(t, 20, edits(lines))
+	})
+
+	t.Run("completely different large files", func(t *testing.T) {
+		oldLines := append(append([]string{"same\n"}, numbered("old ", 5000)...), "end\n")
+		newLines := append(append([]string{"same\n"}, numbered("new ", 5000)...), "end\n")
+
+		lines := Lines(oldLines, newLines)
+
+		gotOld, gotNew := apply(lines)
+		require.Equal(t, oldLines, gotOld)
+		require.Equal(t, newLines, gotNew)
+
+		// The differing lines are replaced as a whole.
+		require.Equal(t, Line{Kind: Equal, Text: "same\n"}, lines[0])
+		require.Equal(t, Line{Kind: Delete, Text: "old 0\n"}, lines[1])
+		require.Equal(t, Line{Kind: Insert, Text: "new 0\n"}, lines[5001])
+		require.Equal(t, Line{Kind: Equal, Text: "end\n"}, lines[len(lines)-1])
+	})
+}
+
+func TestWriteUnified(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	err := WriteUnified(out, []byte("a:  1\nb: 2\nc: 3"), []byte("a: 1\nb: 2\nc: 3\n"), UnifiedOptions{
+		OldLabel: "got",
+		NewLabel: "want",
+		Context:  5,
+	})
+	require.NoError(t, err)
+
+	expected := `--- got
++++ want
+@@ -1,3 +1,3 @@
+-a:  1
++a: 1
+ b: 2
+-c: 3
+\ No newline at end of file
++c: 3
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected diff output (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// ANSI escape sequences used for colored output.
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorCyan   = "\x1b[36m"
+	noColorCode = ""
+)
+
+// UnifiedOptions configures how a unified diff is written.
+type UnifiedOptions struct {
+	// OldLabel and NewLabel are used in the diff's header lines in place of file
+	// names.
+	OldLabel, NewLabel string
+
+	// Context is the number of unchanged lines to show around each change.
+	Context int
+
+	// Color specifies whether to use ANSI escape sequences to color the output.
+	Color bool
+}
+
+// WriteUnified writes the differences from the old text to the new text to w,
+// in the unified diff format used by `diff -u`. Nothing is written if the
+// texts are equal.
+func WriteUnified(w io.Writer, oldText, newText []byte, options UnifiedOptions) error {
+	hunks := Hunks(oldText, newText, options.Context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	color := func(code string) string {
+		if !options.Color {
+			return noColorCode
+		}
+		return code
+	}
+	reset := color(colorReset)
+
+	sb := new(strings.Builder)
+
+	fmt.Fprintf(sb, "%s--- %s%s\n", color(colorBold), options.OldLabel, reset)
+	fmt.Fprintf(sb, "%s+++ %s%s\n", color(colorBold), options.NewLabel, reset)
+
+	for _, h := range hunks {
+		fmt.Fprintf(sb, "%s@@ -%s +%s @@%s\n", color(colorCyan), formatRange(h.OldStart, h.OldLines), formatRange(h.NewStart, h.NewLines), reset)
+
+		for _, l := range h.Lines {
+			var prefix, code string
+			switch l.Kind {
+			case Equal:
+				prefix = " "
+			case Delete:
+				prefix, code = "-", color(colorRed)
+			case Insert:
+				prefix, code = "+", color(colorGreen)
+			}
+
+			text := strings.TrimSuffix(l.Text, "\n")
+			if code != noColorCode {
+				sb.WriteString(code + prefix + text + reset + "\n")
+			} else {
+				sb.WriteString(prefix + text + "\n")
+			}
+
+			if !strings.HasSuffix(l.Text, "\n") {
+				sb.WriteString(noNewlineMarker)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatRange formats a hunk's line range the way `diff -u` does, which omits
+// the line count when it's 1.
+func formatRange(start, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, lines)
+}
@@ -0,0 +1,204 @@
+// Package glob implements matching of slash-separated file paths against
+// "doublestar" glob patterns.
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// ErrBadPattern indicates a pattern was malformed.
+var ErrBadPattern = path.ErrBadPattern
+
+const doubleStar = "**"
+
+// Match reports whether name matches the given glob pattern. Both the pattern
+// and name use forward slashes as path separators.
+//
+// In addition to the syntax supported by path.Match, a pattern may use:
+//
+//   - "**" as an entire path segment, which matches zero or more path segments
+//   - "{a,b,c}", which matches any one of the comma-separated alternatives
+//
+// The only possible returned error is ErrBadPattern, when pattern is
+// malformed.
+func Match(pattern, name string) (bool, error) {
+	alternatives, err := expandBraces(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	nameSegments := strings.Split(name, "/")
+
+	for _, alt := range alternatives {
+		matched, err := matchSegments(strings.Split(alt, "/"), nameSegments)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Validate returns ErrBadPattern if the given pattern is malformed.
+func Validate(pattern string) error {
+	alternatives, err := expandBraces(pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, alt := range alternatives {
+		for _, segment := range strings.Split(alt, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MatchAny reports whether name matches any of the given patterns.
+func MatchAny(patterns []string, name string) (bool, error) {
+	for _, p := range patterns {
+		matched, err := Match(p, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == doubleStar {
+			// Collapse consecutive "**" segments, since they're equivalent to one.
+			for len(pattern) > 1 && pattern[1] == doubleStar {
+				pattern = pattern[1:]
+			}
+
+			for i := 0; i <= len(name); i++ {
+				matched, err := matchSegments(pattern[1:], name[i:])
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0, nil
+}
+
+// expandBraces returns every pattern described by the brace alternations in
+// the given pattern. For example, "a/{b,c}.yaml" expands to "a/b.yaml" and
+// "a/c.yaml".
+func expandBraces(pattern string) ([]string, error) {
+	start, end, err := findBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if start == -1 {
+		return []string{pattern}, nil
+	}
+
+	prefix, body, suffix := pattern[:start], pattern[start+1:end], pattern[end+1:]
+
+	var result []string
+	for _, alt := range splitAlternatives(body) {
+		expanded, err := expandBraces(prefix + alt + suffix)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expanded...)
+	}
+
+	return result, nil
+}
+
+// findBraces returns the indices of the first unescaped opening brace in the
+// pattern and its matching closing brace, or -1 for both if the pattern has no
+// braces.
+func findBraces(pattern string) (int, int, error) {
+	start, depth := -1, 0
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+
+		case '}':
+			// A closing brace without an opening brace is just a literal character.
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				return start, i, nil
+			}
+		}
+	}
+
+	if depth != 0 {
+		return 0, 0, ErrBadPattern
+	}
+
+	return -1, -1, nil
+}
+
+// splitAlternatives splits the body of a brace alternation on its top-level
+// commas.
+func splitAlternatives(body string) []string {
+	var result []string
+	last, depth := 0, 0
+
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, body[last:i])
+				last = i + 1
+			}
+		}
+	}
+
+	return append(result, body[last:])
+}
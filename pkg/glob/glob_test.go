@@ -0,0 +1,50 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.yaml", name: "a.yaml", want: true},
+		{pattern: "*.yaml", name: "dir/a.yaml", want: false},
+		{pattern: "**/*.yaml", name: "a.yaml", want: true},
+		{pattern: "**/*.yaml", name: "dir/sub/a.yaml", want: true},
+		{pattern: "dir/**", name: "dir", want: true},
+		{pattern: "dir/**", name: "dir/sub/a.yaml", want: true},
+		{pattern: "dir/**/a.yaml", name: "dir/a.yaml", want: true},
+		{pattern: "dir/**/a.yaml", name: "dir/x/y/a.yaml", want: true},
+		{pattern: "dir/**/a.yaml", name: "other/a.yaml", want: false},
+		{pattern: "**/.git", name: ".git", want: true},
+		{pattern: "**/.git", name: "sub/.git", want: true},
+		{pattern: "*.{yaml,yml}", name: "a.yml", want: true},
+		{pattern: "*.{yaml,yml}", name: "a.json", want: false},
+		{pattern: "{a,b/{c,d}}/x", name: "b/d/x", want: true},
+		{pattern: `\{a,b}`, name: "{a,b}", want: true},
+		{pattern: "a?c/[0-9].yaml", name: "abc/7.yaml", want: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			got, err := Match(tt.pattern, tt.name)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, pattern := range []string{"[", "{a,b", "**/[z-a"} {
+		t.Run(pattern, func(t *testing.T) {
+			assert.ErrorIs(t, Validate(pattern), ErrBadPattern)
+		})
+	}
+
+	assert.NoError(t, Validate("**/*.{yaml,yml}"))
+}
@@ -9,9 +9,33 @@ type FS interface {
 	Open(name string) (fs.File, error)
 	OpenRW(name string) (File, error)
 	Truncate(name string, size int64) error
+
+	// CreateTemp creates a new file in the directory dir, opened for writing, in
+	// the same way as os.CreateTemp. It returns the file and the file's name,
+	// which includes dir.
+	CreateTemp(dir, pattern string) (File, string, error)
+
+	// EvalSymlinks returns the name of the file that the named file refers to,
+	// after following any symlinks.
+	EvalSymlinks(name string) (string, error)
+
+	// Rename moves oldname to newname, replacing newname if it already exists.
+	Rename(oldname, newname string) error
+
+	// Remove removes the named file.
+	Remove(name string) error
+
+	// Chmod changes the mode of the named file.
+	Chmod(name string, mode fs.FileMode) error
+
+	// Chown changes the numeric user and group IDs of the named file.
+	Chown(name string, uid, gid int) error
 }
 
 type File interface {
 	fs.File
 	io.Writer
+
+	// Sync commits what was written to the file to stable storage.
+	Sync() error
 }
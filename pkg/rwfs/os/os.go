@@ -3,13 +3,12 @@ package os
 import (
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/chainguard-dev/yam/pkg/rwfs"
 )
 
-var defaultFilePerm = fs.FileMode(0o0755)
-
 type FS struct {
 	rootDir string
 }
@@ -23,7 +22,7 @@ func (fsys FS) Open(name string) (fs.File, error) {
 
 func (fsys FS) OpenRW(name string) (rwfs.File, error) {
 	p := fsys.fullPath(name)
-	return os.OpenFile(p, os.O_RDWR, defaultFilePerm)
+	return os.OpenFile(p, os.O_RDWR, 0)
 }
 
 func (fsys FS) Truncate(name string, size int64) error {
@@ -31,6 +30,67 @@ func (fsys FS) Truncate(name string, size int64) error {
 	return os.Truncate(p, size)
 }
 
+func (fsys FS) CreateTemp(dir, pattern string) (rwfs.File, string, error) {
+	p := fsys.fullPath(dir)
+	f, err := os.CreateTemp(p, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return f, path.Join(dir, filepath.Base(f.Name())), nil
+}
+
+// EvalSymlinks returns the name of the file that the named file refers to,
+// relative to the root directory. The file may be outside of the root
+// directory, in which case the name starts with "..".
+func (fsys FS) EvalSymlinks(name string) (string, error) {
+	target, err := filepath.EvalSymlinks(fsys.fullPath(name))
+	if err != nil {
+		return "", err
+	}
+
+	root, err := filepath.EvalSymlinks(fsys.rootDir)
+	if err != nil {
+		return "", err
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+func (fsys FS) Rename(oldname, newname string) error {
+	return os.Rename(fsys.fullPath(oldname), fsys.fullPath(newname))
+}
+
+func (fsys FS) Remove(name string) error {
+	p := fsys.fullPath(name)
+	return os.Remove(p)
+}
+
+func (fsys FS) Chmod(name string, mode fs.FileMode) error {
+	p := fsys.fullPath(name)
+	return os.Chmod(p, mode)
+}
+
+func (fsys FS) Chown(name string, uid, gid int) error {
+	p := fsys.fullPath(name)
+	return os.Chown(p, uid, gid)
+}
+
 func (fsys FS) fullPath(name string) string {
 	// TODO: ensure this join doesn't result in a path outside of rootDir's subtree.
 
@@ -0,0 +1,107 @@
+package os
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/chainguard-dev/yam/pkg/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "a.yaml")
+	require.NoError(t, os.WriteFile(p, []byte("a:   1\n"), 0o640))
+
+	fsys := DirFS(dir)
+	require.NoError(t, rwfs.WriteFile(fsys, "a.yaml", []byte("a: 1\n")))
+
+	content, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	}
+
+	// No temporary files should be left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFile_symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires extra privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "real"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "real", "a.yaml"), []byte("a:   1\n"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("real", "a.yaml"), filepath.Join(dir, "link.yaml")))
+
+	fsys := &tempDirRecorder{FS: DirFS(dir)}
+	require.NoError(t, rwfs.WriteFile(fsys, "link.yaml", []byte("a: 1\n")))
+
+	// The temporary file is created next to the target, so that renaming it
+	// onto the target doesn't cross file systems.
+	assert.Equal(t, []string{"real"}, fsys.dirs)
+
+	info, err := os.Lstat(filepath.Join(dir, "link.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode().Type())
+
+	content, err := os.ReadFile(filepath.Join(dir, "real", "a.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+}
+
+func TestWriteFile_symlinkOutsideRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires extra privileges on Windows")
+	}
+
+	dir, other := t.TempDir(), t.TempDir()
+	target := filepath.Join(other, "a.yaml")
+	require.NoError(t, os.WriteFile(target, []byte("a:   1\n"), 0o644))
+	require.NoError(t, os.Symlink(target, filepath.Join(dir, "link.yaml")))
+
+	require.NoError(t, rwfs.WriteFile(DirFS(dir), "link.yaml", []byte("a: 1\n")))
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	for _, d := range []string{dir, other} {
+		entries, err := os.ReadDir(d)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+}
+
+// tempDirRecorder records the directories that temporary files are created in.
+type tempDirRecorder struct {
+	rwfs.FS
+	dirs []string
+}
+
+func (r *tempDirRecorder) CreateTemp(dir, pattern string) (rwfs.File, string, error) {
+	r.dirs = append(r.dirs, dir)
+	return r.FS.CreateTemp(dir, pattern)
+}
+
+func TestWriteFile_missing(t *testing.T) {
+	dir := t.TempDir()
+
+	err := rwfs.WriteFile(DirFS(dir), "missing.yaml", []byte("a: 1\n"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
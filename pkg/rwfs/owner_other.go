@@ -0,0 +1,11 @@
+//go:build !unix
+
+package rwfs
+
+import "io/fs"
+
+// owner returns the user and group IDs of the file described by info, if
+// they're available. They never are on this platform.
+func owner(fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
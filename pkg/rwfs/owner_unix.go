@@ -0,0 +1,19 @@
+//go:build unix
+
+package rwfs
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// owner returns the user and group IDs of the file described by info, if
+// they're available.
+func owner(info fs.FileInfo) (uid, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
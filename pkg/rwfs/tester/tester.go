@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/chainguard-dev/yam/pkg/rwfs"
 	"github.com/google/go-cmp/cmp"
@@ -21,7 +24,12 @@ const specialFileContentForSkippingDiff = "# skip"
 var expectedSuffixWithYAML = expectedSuffix + ".yaml"
 
 type FS struct {
+	// mu guards fixtures, so that files can be processed concurrently.
+	mu       sync.Mutex
 	fixtures map[string]*testFile
+
+	// tempCount is used to give each file from CreateTemp a unique name.
+	tempCount int
 }
 
 func NewFS(fixtures ...string) (*FS, error) {
@@ -84,6 +92,9 @@ func expectedName(original string) string {
 }
 
 func (fsys *FS) Open(name string) (fs.File, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
 	if f, ok := fsys.fixtures[name]; ok {
 		return f, nil
 	}
@@ -92,6 +103,9 @@ func (fsys *FS) Open(name string) (fs.File, error) {
 }
 
 func (fsys *FS) OpenRW(name string) (rwfs.File, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
 	if f, ok := fsys.fixtures[name]; ok {
 		return f, nil
 	}
@@ -104,10 +118,105 @@ func (fsys *FS) Truncate(string, int64) error {
 	return nil
 }
 
+func (fsys *FS) CreateTemp(dir, pattern string) (rwfs.File, string, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	fsys.tempCount++
+	name := path.Join(dir, strings.Replace(pattern, "*", strconv.Itoa(fsys.tempCount), 1))
+	if !strings.Contains(pattern, "*") {
+		name += strconv.Itoa(fsys.tempCount)
+	}
+
+	tf := new(testFile)
+	tf.originalRead = new(bytes.Buffer)
+	tf.writtenBack = new(bytes.Buffer)
+	tf.path = name
+
+	fsys.addTestFile(name, tf)
+
+	return tf, name, nil
+}
+
+// EvalSymlinks returns name, since fixtures are never symlinks.
+func (fsys *FS) EvalSymlinks(name string) (string, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if _, ok := fsys.fixtures[name]; !ok {
+		return "", os.ErrNotExist
+	}
+
+	return name, nil
+}
+
+// Rename moves what was written to oldname to newname. If newname is a fixture
+// file, it's kept as a fixture, so that the written content can be compared
+// with the fixture's expected content.
+func (fsys *FS) Rename(oldname, newname string) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	src, ok := fsys.fixtures[oldname]
+	if !ok {
+		return os.ErrNotExist
+	}
+	delete(fsys.fixtures, oldname)
+
+	if dst, ok := fsys.fixtures[newname]; ok {
+		dst.writtenBack = src.writtenBack
+		dst.written = true
+		return nil
+	}
+
+	src.path = newname
+	fsys.addTestFile(newname, src)
+
+	return nil
+}
+
+func (fsys *FS) Remove(name string) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if _, ok := fsys.fixtures[name]; !ok {
+		return os.ErrNotExist
+	}
+
+	delete(fsys.fixtures, name)
+	return nil
+}
+
+func (fsys *FS) Chmod(name string, _ fs.FileMode) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if _, ok := fsys.fixtures[name]; !ok {
+		return os.ErrNotExist
+	}
+
+	return nil
+}
+
+func (fsys *FS) Chown(name string, _, _ int) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if _, ok := fsys.fixtures[name]; !ok {
+		return os.ErrNotExist
+	}
+
+	return nil
+}
+
 func (fsys *FS) Diff(name string) string {
 	if tf, ok := fsys.fixtures[name]; ok {
 		want := tf.expectedRead
 		got := tf.writtenBack
+		if !tf.written {
+			// The file was left as it was.
+			got = bytes.NewBuffer(tf.original)
+		}
 
 		if want.String() == specialFileContentForSkippingDiff {
 			return ""
@@ -128,6 +237,15 @@ func (fsys *FS) Diff(name string) string {
 	return fmt.Sprintf("unable to find test file %q in tester.FS", name)
 }
 
+// Written reports whether the named file was written to, or replaced.
+func (fsys *FS) Written(name string) bool {
+	if tf, ok := fsys.fixtures[name]; ok {
+		return tf.written
+	}
+
+	return false
+}
+
 func (fsys *FS) DiffAll() string {
 	fixtureFiles := lo.Keys(fsys.fixtures)
 	sort.Strings(fixtureFiles)
@@ -168,6 +286,7 @@ func (fsys *FS) addFixtureFileFromOS(path string) error {
 	}
 
 	tf := new(testFile)
+	tf.original = originalBytes
 	tf.originalRead = bytes.NewBuffer(originalBytes)
 	tf.expectedRead = bytes.NewBuffer(expectedBytes)
 	tf.writtenBack = new(bytes.Buffer)
@@ -191,7 +310,11 @@ func (fsys *FS) addDir(path string) {
 type testFile struct {
 	path                                    string
 	isDir                                   bool
+	original                                []byte
 	originalRead, expectedRead, writtenBack *bytes.Buffer
+
+	// written reports whether the file was written to, or replaced.
+	written bool
 }
 
 func (t *testFile) ReadDir(_ int) ([]fs.DirEntry, error) {
@@ -224,5 +347,10 @@ func (t *testFile) Close() error {
 }
 
 func (t *testFile) Write(p []byte) (n int, err error) {
+	t.written = true
 	return t.writtenBack.Write(p)
 }
+
+func (t *testFile) Sync() error {
+	return nil
+}
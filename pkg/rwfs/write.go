@@ -0,0 +1,67 @@
+package rwfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// WriteFile replaces the content of the named file with data. The data is
+// written to a temporary file in the same directory, which is then renamed to
+// the file's name, so that the file is never left partially written. The
+// file's mode bits are kept, as is its ownership, where possible. If the named
+// file is a symlink, the file it links to is replaced, and the link is kept.
+func WriteFile(fsys FS, name string, data []byte) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	// The temporary file needs to be in the target's directory, since a rename
+	// can't move a file across file systems.
+	target, err := fsys.EvalSymlinks(name)
+	if err != nil {
+		return fmt.Errorf("resolving %q: %w", name, err)
+	}
+
+	tmp, tmpName, err := fsys.CreateTemp(path.Dir(target), "."+path.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file for %q: %w", name, err)
+	}
+
+	renamed := false
+	defer func() {
+		if !renamed {
+			_ = fsys.Remove(tmpName)
+		}
+	}()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing temporary file for %q: %w", name, err)
+	}
+
+	err = fsys.Chmod(tmpName, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("setting mode of temporary file for %q: %w", name, err)
+	}
+
+	if uid, gid, ok := owner(info); ok {
+		// Only privileged users can give a file away, so this is best effort.
+		_ = fsys.Chown(tmpName, uid, gid)
+	}
+
+	err = fsys.Rename(tmpName, target)
+	if err != nil {
+		return fmt.Errorf("replacing %q: %w", name, err)
+	}
+	renamed = true
+
+	return nil
+}
@@ -13,3 +13,7 @@ func IsYAML(path string) bool {
 }
 
 const ConfigFileName = ".yam.yaml"
+
+// IgnoreFileName is the name of the file that lists paths yam should skip,
+// using the same syntax as a .gitignore file.
+const IgnoreFileName = ".yamignore"
@@ -7,7 +7,6 @@ import (
 	"io"
 
 	"github.com/chainguard-dev/yam/pkg/yam/formatted"
-	"gopkg.in/yaml.v3"
 )
 
 func applyFormatting(input io.Reader, options FormatOptions) (*bytes.Buffer, error) {
@@ -24,13 +23,6 @@ func applyFormatting(input io.Reader, options FormatOptions) (*bytes.Buffer, err
 		b = ensureFinalNewline(b)
 	}
 
-	root := &yaml.Node{}
-	decoder := yaml.NewDecoder(bytes.NewReader(b))
-	err = decoder.Decode(root)
-	if err != nil {
-		return nil, err
-	}
-
 	buf := new(bytes.Buffer)
 	enc := formatted.NewEncoder(buf)
 	enc, err = enc.UseOptions(options.EncodeOptions)
@@ -38,7 +30,7 @@ func applyFormatting(input io.Reader, options FormatOptions) (*bytes.Buffer, err
 		return nil, fmt.Errorf("unable to use options with encoder: %w", err)
 	}
 
-	err = enc.Encode(root)
+	err = enc.EncodeStream(bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,38 @@
+package yam
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Cache records file contents that are known to be formatted already, so that
+// they can be skipped rather than formatted again. It's implemented by
+// *cache.Cache.
+type Cache interface {
+	// Contains reports whether the content is known to be formatted according
+	// to the given encoding of the formatting options.
+	Contains(content, options []byte) bool
+
+	// Add records that the content is formatted according to the given
+	// encoding of the formatting options.
+	Add(content, options []byte) error
+}
+
+// cacheOptions returns an encoding of the options that affect how content is
+// formatted, for use with a Cache.
+func cacheOptions(options FormatOptions) ([]byte, error) {
+	b, err := json.Marshal(struct {
+		EncodeOptions          any
+		FinalNewline           bool
+		TrimTrailingWhitespace bool
+	}{
+		EncodeOptions:          options.EncodeOptions,
+		FinalNewline:           options.FinalNewline,
+		TrimTrailingWhitespace: options.TrimTrailingWhitespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode formatting options for the cache: %w", err)
+	}
+
+	return b, nil
+}
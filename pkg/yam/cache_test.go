@@ -0,0 +1,108 @@
+package yam
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/chainguard-dev/yam/pkg/rwfs/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapCache is an in-memory Cache.
+type mapCache map[string]bool
+
+func (c mapCache) Contains(content, options []byte) bool {
+	return c[string(options)+"\x00"+string(content)]
+}
+
+func (c mapCache) Add(content, options []byte) error {
+	c[string(options)+"\x00"+string(content)] = true
+	return nil
+}
+
+func Test_formatSingleFileWithCache(t *testing.T) {
+	const (
+		formattedFixture   = "testdata/format/unchanged.yaml"
+		unformattedFixture = "testdata/format/simple.yaml"
+	)
+
+	t.Run("formatted files are added", func(t *testing.T) {
+		fsys, err := tester.NewFS(formattedFixture, unformattedFixture)
+		require.NoError(t, err)
+
+		options := testOptions
+		c := mapCache{}
+		options.Cache = c
+
+		_, err = formatSingleFile(fsys, formattedFixture, options)
+		require.NoError(t, err)
+		_, err = formatSingleFile(fsys, unformattedFixture, options)
+		require.NoError(t, err)
+
+		// Only content that was already formatted is added, since that's the only
+		// content known to be formatted.
+		assert.Len(t, c, 1)
+	})
+
+	t.Run("cached files are skipped", func(t *testing.T) {
+		fsys, err := tester.NewFS(unformattedFixture)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(unformattedFixture)
+		require.NoError(t, err)
+
+		options := testOptions
+		c := mapCache{}
+		require.NoError(t, c.Add(content, testCacheOptions(t, options)))
+		options.Cache = c
+
+		result, err := formatSingleFile(fsys, unformattedFixture, options)
+		require.NoError(t, err)
+		assert.False(t, result.Changed)
+		assert.False(t, fsys.Written(unformattedFixture))
+
+		// The cache is only used for the options it was populated with.
+		options.EncodeOptions.Indent = 4
+
+		result, err = formatSingleFile(fsys, unformattedFixture, options)
+		require.NoError(t, err)
+		assert.True(t, result.Changed)
+	})
+}
+
+func TestLintFilesWithCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"good.yaml": {Data: []byte("a: 1\n")},
+		"bad.yaml":  {Data: []byte("a:   1\n")},
+	}
+
+	options := testOptions
+	c := mapCache{}
+	options.Cache = c
+
+	results, err := LintFiles(fsys, []string{"good.yaml", "bad.yaml"}, options)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Passed())
+	assert.False(t, results[1].Passed())
+	assert.Len(t, c, 1)
+
+	// Content in the cache passes without being formatted.
+	require.NoError(t, c.Add([]byte("a:   1\n"), testCacheOptions(t, options)))
+
+	results, err = LintFiles(fsys, []string{"bad.yaml"}, options)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed())
+}
+
+func testCacheOptions(t *testing.T, options FormatOptions) []byte {
+	t.Helper()
+
+	b, err := cacheOptions(options)
+	require.NoError(t, err)
+
+	return b
+}
@@ -0,0 +1,626 @@
+package yam
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/yam/pkg/glob"
+	"github.com/chainguard-dev/yam/pkg/util"
+	"github.com/chainguard-dev/yam/pkg/yam/formatted"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent is the indent used when no config or flag sets one.
+const defaultIndent = 2
+
+// FormatConfig is the content of a yam config file. Fields that aren't set in
+// the file are nil, so that they can be told apart from values that are set to
+// the zero value.
+type FormatConfig struct {
+	// Root specifies that config files in parent directories shouldn't be
+	// applied to the files this config applies to.
+	Root bool `yaml:"root"`
+
+	// Extends lists the paths of other config files that this config builds
+	// on, relative to the directory this config is in. They're merged in order,
+	// and this config is merged on top of them.
+	Extends []string `yaml:"extends"`
+
+	Indent *int     `yaml:"indent"`
+	Gap    []string `yaml:"gap"`
+	Sort   []string `yaml:"sort"`
+	Quote  []string `yaml:"quote"`
+	Dedup  []string `yaml:"dedup"`
+
+	FinalNewline *bool `yaml:"final-newline"`
+	TrimLines    *bool `yaml:"trim-lines"`
+
+	Recursive *bool    `yaml:"recursive"`
+	Include   []string `yaml:"include"`
+	Exclude   []string `yaml:"exclude"`
+
+	// Overrides lists settings that only apply to some files. They're applied
+	// in order, after the rest of the config.
+	Overrides []ConfigOverride `yaml:"overrides"`
+
+	// reset lists the settings whose lists replace the lists of the configs
+	// this config is merged on top of, rather than being appended to them.
+	reset []string
+
+	// sources records where the config's values came from.
+	sources configSources
+}
+
+// ConfigOverride is a set of settings from a config file that only apply to
+// files matching its patterns. Its settings are merged into the rest of the
+// config in the same way as a config in a subdirectory.
+type ConfigOverride struct {
+	// Files lists doublestar glob patterns (e.g. ".github/workflows/*.yaml")
+	// for the files that the override applies to. The patterns are relative to
+	// the directory the config file is in, and patterns without a slash match
+	// files in any subdirectory.
+	Files []string `yaml:"files"`
+
+	Indent *int     `yaml:"indent"`
+	Gap    []string `yaml:"gap"`
+	Sort   []string `yaml:"sort"`
+	Quote  []string `yaml:"quote"`
+	Dedup  []string `yaml:"dedup"`
+
+	FinalNewline *bool `yaml:"final-newline"`
+	TrimLines    *bool `yaml:"trim-lines"`
+
+	// dir is the absolute path of the directory the patterns are relative to.
+	dir string
+
+	// reset lists the settings whose lists replace the config's lists.
+	reset []string
+
+	// sources records where the override's values came from.
+	sources configSources
+}
+
+// MarshalYAML encodes only the settings that are set in the config, so that it
+// can be written out as a config file. Lists that reset the lists they're
+// merged with are tagged with !reset.
+func (c FormatConfig) MarshalYAML() (any, error) {
+	return marshalSettings(reflect.ValueOf(c), c.reset)
+}
+
+// MarshalYAML encodes only the settings that are set in the override.
+func (o ConfigOverride) MarshalYAML() (any, error) {
+	return marshalSettings(reflect.ValueOf(o), o.reset)
+}
+
+// marshalSettings returns a mapping node with the settings that are set in v,
+// which is a FormatConfig or a ConfigOverride, in the order they're declared.
+func marshalSettings(v reflect.Value, reset []string) (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "" || name == "-" || v.Field(i).IsZero() {
+			continue
+		}
+
+		value := new(yaml.Node)
+		if err := value.Encode(v.Field(i).Interface()); err != nil {
+			return nil, err
+		}
+		if slices.Contains(reset, name) {
+			value.Tag = resetTag
+		}
+
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	return n, nil
+}
+
+// matches reports whether the override applies to the file at the given
+// absolute path.
+func (o ConfigOverride) matches(p string) (bool, error) {
+	rel, err := filepath.Rel(o.dir, p)
+	if err != nil {
+		return false, nil
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return false, nil
+	}
+
+	for _, pattern := range o.Files {
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+
+		matched, err := glob.Match(strings.TrimPrefix(pattern, "/"), rel)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// config returns the override's settings as a FormatConfig.
+func (o ConfigOverride) config() FormatConfig {
+	return FormatConfig{
+		Indent: o.Indent,
+		Gap:    o.Gap,
+		Sort:   o.Sort,
+		Quote:  o.Quote,
+		Dedup:  o.Dedup,
+
+		FinalNewline: o.FinalNewline,
+		TrimLines:    o.TrimLines,
+
+		reset:   o.reset,
+		sources: o.sources,
+	}
+}
+
+// forFile returns the config with the overrides that match the file at the
+// given absolute path merged into it.
+func (c FormatConfig) forFile(p string) (FormatConfig, error) {
+	cfg := c
+	cfg.Overrides = nil
+
+	for _, o := range c.Overrides {
+		matched, err := o.matches(p)
+		if err != nil {
+			return FormatConfig{}, err
+		}
+		if matched {
+			cfg = cfg.Merge(o.config())
+		}
+	}
+
+	return cfg, nil
+}
+
+// readConfig decodes a yam config file from r. The config is checked before
+// it's decoded, and any problems with it are returned as ConfigErrors, using
+// name as their path. The patterns in the config's overrides are taken to be
+// relative to dir, which must be absolute.
+func readConfig(r io.Reader, name, dir string) (FormatConfig, error) {
+	var doc yaml.Node
+
+	err := yaml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return FormatConfig{}, nil
+		}
+
+		return FormatConfig{}, fmt.Errorf("parsing yam config: %w", err)
+	}
+
+	if err := checkConfig(&doc, name); err != nil {
+		return FormatConfig{}, err
+	}
+
+	// Take note of the lists tagged with !reset before decoding, since the
+	// decoder doesn't know about the tag.
+	var reset []string
+	var overridesReset [][]string
+	if root := documentRoot(&doc); root.Kind == yaml.MappingNode {
+		reset = takeResetTags(root)
+
+		if overrides := mappingValue(root, "overrides"); overrides != nil && overrides.Kind == yaml.SequenceNode {
+			for _, o := range overrides.Content {
+				overridesReset = append(overridesReset, takeResetTags(o))
+			}
+		}
+	}
+
+	var cfg FormatConfig
+	if err := doc.Decode(&cfg); err != nil {
+		return FormatConfig{}, fmt.Errorf("parsing yam config: %w", err)
+	}
+
+	cfg.reset = reset
+	cfg.sources = sourcesOf(cfg, name)
+	for i := range cfg.Overrides {
+		o := &cfg.Overrides[i]
+		o.dir = dir
+		o.reset = overridesReset[i]
+		o.sources = sourcesOf(*o, fmt.Sprintf("%s (override %d)", name, i+1))
+	}
+
+	return cfg, nil
+}
+
+// extendConfig returns the result of merging cfg on top of the configs it
+// extends, which are read from paths relative to base. Patterns in the
+// overrides of extended configs are relative to dir, like cfg's own. chain
+// lists the absolute paths of the config files being extended, to detect
+// cycles. merged has the absolute paths of the config files that have already
+// been merged into the config being read, which are skipped, so that a file
+// that's extended more than once, e.g. by two of the files cfg extends, is only
+// merged once.
+func extendConfig(cfg FormatConfig, base, dir string, chain []string, merged map[string]bool) (FormatConfig, error) {
+	if len(cfg.Extends) == 0 {
+		return cfg, nil
+	}
+
+	var extended FormatConfig
+	for _, p := range cfg.Extends {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(base, filepath.FromSlash(p))
+		}
+
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return FormatConfig{}, fmt.Errorf("finding absolute path of %q: %w", p, err)
+		}
+		if merged[abs] {
+			continue
+		}
+
+		other, err := readConfigFileChain(p, dir, chain, merged)
+		if err != nil {
+			return FormatConfig{}, fmt.Errorf("extending %q: %w", p, err)
+		}
+		merged[abs] = true
+
+		extended = extended.Merge(other)
+	}
+
+	result := extended.Merge(cfg)
+
+	// Whether to look in parent directories depends on where the config is, so
+	// it's not taken from extended configs.
+	result.Root = cfg.Root
+	result.Extends = nil
+
+	return result, nil
+}
+
+// ReadFormatConfig decodes a yam config file from r. Settings that aren't in
+// the file are left unset, so that the config can be combined with others
+// using Merge and Override before being turned into FormatOptions. The patterns
+// in the config's overrides are relative to the current working directory.
+//
+// Unknown settings, values of the wrong type, and invalid path expressions and
+// glob patterns are all reported, as ConfigErrors joined with errors.Join.
+func ReadFormatConfig(r io.Reader) (FormatConfig, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return FormatConfig{}, fmt.Errorf("finding working directory: %w", err)
+	}
+
+	cfg, err := readConfig(r, "", dir)
+	if err != nil {
+		return FormatConfig{}, err
+	}
+
+	return extendConfig(cfg, dir, dir, nil, make(map[string]bool))
+}
+
+// ReadConfigFile reads the yam config file at the given path. The patterns in
+// the config's overrides are relative to the directory the file is in. Problems
+// with the config are reported in the same way as by ReadFormatConfig.
+func ReadConfigFile(path string) (FormatConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return FormatConfig{}, fmt.Errorf("finding absolute path of %q: %w", path, err)
+	}
+
+	return readConfigFile(path, filepath.Dir(abs))
+}
+
+// readConfigFile reads the yam config file at the given path, with the patterns
+// in its overrides relative to dir. The configs it extends are merged into it.
+func readConfigFile(path, dir string) (FormatConfig, error) {
+	return readConfigFileChain(path, dir, nil, make(map[string]bool))
+}
+
+// readConfigFileChain is readConfigFile for a config file that's extended by
+// the config files in chain.
+func readConfigFileChain(path, dir string, chain []string, merged map[string]bool) (FormatConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return FormatConfig{}, fmt.Errorf("finding absolute path of %q: %w", path, err)
+	}
+
+	if slices.Contains(chain, abs) {
+		cycle := make([]string, 0, len(chain)+1)
+		for _, p := range append(chain, abs) {
+			cycle = append(cycle, displayPath(p))
+		}
+
+		return FormatConfig{}, fmt.Errorf("config files extend each other in a cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatConfig{}, fmt.Errorf("opening yam config: %w", err)
+	}
+	defer f.Close()
+
+	name := displayPath(abs)
+	cfg, err := readConfig(f, name, dir)
+	if err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			// The errors already name the file.
+			return FormatConfig{}, err
+		}
+
+		return FormatConfig{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+
+	return extendConfig(cfg, filepath.Dir(abs), dir, append(slices.Clip(chain), abs), merged)
+}
+
+// Merge returns the result of applying a more specific config, such as one in
+// a subdirectory, on top of this one. The other config's values replace this
+// config's scalar values, and are appended to its lists, unless the other
+// config tagged the list with !reset, in which case it replaces this config's
+// list. Root isn't merged, so the merged config keeps this config's Root.
+func (c FormatConfig) Merge(other FormatConfig) FormatConfig {
+	merged := c
+
+	mergeList := func(setting string, list, otherList []string) []string {
+		if slices.Contains(other.reset, setting) {
+			return otherList
+		}
+
+		return appendList(list, otherList)
+	}
+
+	merged.Indent = override(c.Indent, other.Indent)
+	merged.Gap = mergeList("gap", c.Gap, other.Gap)
+	merged.Sort = mergeList("sort", c.Sort, other.Sort)
+	merged.Quote = mergeList("quote", c.Quote, other.Quote)
+	merged.Dedup = mergeList("dedup", c.Dedup, other.Dedup)
+	merged.FinalNewline = override(c.FinalNewline, other.FinalNewline)
+	merged.TrimLines = override(c.TrimLines, other.TrimLines)
+	merged.Recursive = override(c.Recursive, other.Recursive)
+	merged.Include = mergeList("include", c.Include, other.Include)
+	merged.Exclude = mergeList("exclude", c.Exclude, other.Exclude)
+
+	if slices.Contains(other.reset, "overrides") {
+		merged.Overrides = other.Overrides
+	} else {
+		merged.Overrides = append(slices.Clip(c.Overrides), other.Overrides...)
+	}
+
+	// A list that was reset stays reset when the merged config is merged on
+	// top of another.
+	merged.reset = appendList(c.reset, other.reset)
+	merged.sources = c.sources.merge(other.sources, func(setting string) bool {
+		return !slices.Contains(other.reset, setting)
+	})
+
+	return merged
+}
+
+// Override returns the result of replacing this config's values with the
+// other config's values, for each value that's set in the other config. Unlike
+// Merge, lists are replaced rather than appended to. This is how CLI flags are
+// applied on top of config files.
+func (c FormatConfig) Override(other FormatConfig) FormatConfig {
+	overridden := c
+
+	overridden.Root = c.Root || other.Root
+	overridden.Indent = override(c.Indent, other.Indent)
+	overridden.Gap = overrideList(c.Gap, other.Gap)
+	overridden.Sort = overrideList(c.Sort, other.Sort)
+	overridden.Quote = overrideList(c.Quote, other.Quote)
+	overridden.Dedup = overrideList(c.Dedup, other.Dedup)
+	overridden.FinalNewline = override(c.FinalNewline, other.FinalNewline)
+	overridden.TrimLines = override(c.TrimLines, other.TrimLines)
+	overridden.Recursive = override(c.Recursive, other.Recursive)
+	overridden.Include = overrideList(c.Include, other.Include)
+	overridden.Exclude = overrideList(c.Exclude, other.Exclude)
+	overridden.Overrides = append(slices.Clip(c.Overrides), other.Overrides...)
+	overridden.sources = c.sources.merge(other.sources, func(string) bool {
+		return false
+	})
+
+	return overridden
+}
+
+// FormatOptions returns the FormatOptions described by the config, using
+// default values for anything the config doesn't set.
+func (c FormatConfig) FormatOptions() FormatOptions {
+	indent := defaultIndent
+	if c.Indent != nil {
+		indent = *c.Indent
+	}
+
+	finalNewline := true
+	if c.FinalNewline != nil {
+		finalNewline = *c.FinalNewline
+	}
+
+	trimLines := true
+	if c.TrimLines != nil {
+		trimLines = *c.TrimLines
+	}
+
+	var recursive bool
+	if c.Recursive != nil {
+		recursive = *c.Recursive
+	}
+
+	return FormatOptions{
+		EncodeOptions: formatted.EncodeOptions{
+			Indent:           indent,
+			GapExpressions:   c.Gap,
+			SortExpressions:  c.Sort,
+			QuoteExpressions: c.Quote,
+			DedupExpressions: c.Dedup,
+		},
+		FinalNewline:           finalNewline,
+		TrimTrailingWhitespace: trimLines,
+		DiscoveryOptions: DiscoveryOptions{
+			Recursive: recursive,
+			Include:   c.Include,
+			Exclude:   c.Exclude,
+		},
+	}
+}
+
+// override returns other if it's set, and v otherwise.
+func override[T any](v, other *T) *T {
+	if other != nil {
+		return other
+	}
+
+	return v
+}
+
+// overrideList returns other if it's set, and list otherwise. An empty list
+// that's set replaces list, too.
+func overrideList(list, other []string) []string {
+	if other != nil {
+		return other
+	}
+
+	return list
+}
+
+// appendList returns a new list with the items of both lists.
+func appendList(list, other []string) []string {
+	if list == nil && other == nil {
+		return nil
+	}
+
+	return append(slices.Clip(list), other...)
+}
+
+// ConfigLoader finds and reads the yam config files that apply to files, in
+// the same way as EditorConfig: for each file, the config file (.yam.yaml) in
+// the file's directory and in each of its parent directories applies to it,
+// with configs in nearer directories taking priority. The search stops at a
+// config file that sets "root: true".
+//
+// Config files are only read once, so a ConfigLoader should be discarded if
+// config files change. It's safe for concurrent use.
+type ConfigLoader struct {
+	// dir is the directory that paths are relative to.
+	dir string
+
+	// file, if set, is the only config that's used, in place of searching for
+	// config files.
+	file *FormatConfig
+
+	mu   sync.Mutex
+	dirs map[string]FormatConfig
+}
+
+// NewConfigLoader returns a ConfigLoader for paths relative to dir.
+//
+// If configFile isn't empty, it's the path of the only config file to use for
+// every file, rather than searching for config files. The patterns in its
+// overrides are relative to dir, rather than to the file's directory.
+func NewConfigLoader(dir, configFile string) (*ConfigLoader, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("finding absolute path of %q: %w", dir, err)
+	}
+
+	l := &ConfigLoader{
+		dir:  abs,
+		dirs: make(map[string]FormatConfig),
+	}
+
+	if configFile != "" {
+		cfg, err := readConfigFile(configFile, abs)
+		if err != nil {
+			return nil, err
+		}
+
+		l.file = &cfg
+	}
+
+	return l, nil
+}
+
+// ConfigForFile returns the config for the file at the given path, which uses
+// forward slashes and is relative to the loader's directory. Any overrides
+// that apply to the file are merged into the config.
+func (l *ConfigLoader) ConfigForFile(p string) (FormatConfig, error) {
+	abs := filepath.Join(l.dir, filepath.FromSlash(p))
+
+	cfg, err := l.ConfigForDir(filepath.Dir(filepath.FromSlash(p)))
+	if err != nil {
+		return FormatConfig{}, err
+	}
+
+	return cfg.forFile(abs)
+}
+
+// ConfigForDir returns the merged config for files in the given directory,
+// which is relative to the loader's directory. The config's overrides aren't
+// applied.
+func (l *ConfigLoader) ConfigForDir(dir string) (FormatConfig, error) {
+	if l.file != nil {
+		return *l.file, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.configForDir(filepath.Join(l.dir, dir))
+}
+
+// configForDir returns the merged config for the absolute directory path. The
+// caller must hold l.mu.
+func (l *ConfigLoader) configForDir(dir string) (FormatConfig, error) {
+	if cfg, ok := l.dirs[dir]; ok {
+		return cfg, nil
+	}
+
+	cfg, found, err := readDirConfig(dir)
+	if err != nil {
+		return FormatConfig{}, err
+	}
+
+	if !found || !cfg.Root {
+		parent := filepath.Dir(dir)
+		if parent != dir {
+			parentCfg, err := l.configForDir(parent)
+			if err != nil {
+				return FormatConfig{}, err
+			}
+
+			cfg = parentCfg.Merge(cfg)
+		}
+	}
+
+	l.dirs[dir] = cfg
+	return cfg, nil
+}
+
+// readDirConfig reads the config file in the given directory, if there is one.
+func readDirConfig(dir string) (FormatConfig, bool, error) {
+	p := filepath.Join(dir, util.ConfigFileName)
+
+	cfg, err := readConfigFile(p, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return FormatConfig{}, false, nil
+		}
+
+		return FormatConfig{}, false, err
+	}
+
+	return cfg, true, nil
+}
@@ -0,0 +1,404 @@
+package yam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// withoutSources returns the config without the record of where its values
+// came from, for comparing with configs made in tests.
+func withoutSources(cfg FormatConfig) FormatConfig {
+	cfg.sources = nil
+	return cfg
+}
+
+func TestFormatConfig_Merge(t *testing.T) {
+	parent := FormatConfig{
+		Root:      true,
+		Indent:    ptr(2),
+		Gap:       []string{"."},
+		Recursive: ptr(true),
+	}
+	child := FormatConfig{
+		Indent:       ptr(4),
+		Gap:          []string{".a"},
+		Sort:         []string{".b"},
+		FinalNewline: ptr(false),
+	}
+
+	expected := FormatConfig{
+		Root:         true,
+		Indent:       ptr(4),
+		Gap:          []string{".", ".a"},
+		Sort:         []string{".b"},
+		FinalNewline: ptr(false),
+		Recursive:    ptr(true),
+	}
+	assert.Equal(t, expected, parent.Merge(child))
+
+	// Root isn't taken from the other config.
+	assert.False(t, FormatConfig{}.Merge(FormatConfig{Root: true}).Root)
+
+	// The parent's lists aren't modified.
+	assert.Equal(t, []string{"."}, parent.Gap)
+
+	// A list tagged with !reset replaces the parent's list.
+	child.reset = []string{"gap"}
+	merged := parent.Merge(child)
+	assert.Equal(t, []string{".a"}, merged.Gap)
+	assert.Equal(t, []string{"gap"}, merged.reset)
+}
+
+func TestConfigLoader_extends(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"styles/base.yaml": `root: true
+indent: 4
+gap:
+  - .
+sort:
+  - .a
+overrides:
+  - files:
+      - "*.k8s.yaml"
+    indent: 2
+`,
+		"styles/quotes.yaml": "extends:\n  - base.yaml\nquote:\n  - .q\n",
+		"project/.yam.yaml": `extends:
+  - ../styles/base.yaml
+  - ../styles/quotes.yaml
+gap:
+  - .b
+sort: !reset
+  - .c
+`,
+		"cycle/a.yaml":   "extends: [b.yaml]\n",
+		"cycle/b.yaml":   "extends: [a.yaml]\n",
+		"missing/a.yaml": "extends: [nope.yaml]\n",
+		"diamond/a.yaml": "extends: [b.yaml, c.yaml]\n",
+		"diamond/b.yaml": "extends: [d.yaml]\n",
+		"diamond/c.yaml": "extends: [d.yaml]\n",
+		"diamond/d.yaml": "indent: 3\ngap: [.d]\n",
+	})
+
+	loader, err := NewConfigLoader(filepath.Join(dir, "project"), "")
+	require.NoError(t, err)
+
+	cfg, err := loader.ConfigForFile("a.yaml")
+	require.NoError(t, err)
+
+	// The base config is extended twice, but only merged once. Root isn't taken
+	// from extended configs, so the search continues past the project.
+	expected := FormatConfig{
+		Indent: ptr(4),
+		Gap:    []string{".", ".b"},
+		Sort:   []string{".c"},
+		Quote:  []string{".q"},
+		reset:  []string{"sort"},
+	}
+	assert.Equal(t, expected, withoutSources(cfg))
+
+	// The extended config's override patterns are relative to the project.
+	cfg, err = loader.ConfigForFile("deploy/app.k8s.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, ptr(2), cfg.Indent)
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := ReadConfigFile(filepath.Join(dir, "cycle", "a.yaml"))
+		assert.ErrorContains(t, err, "config files extend each other in a cycle")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ReadConfigFile(filepath.Join(dir, "missing", "a.yaml"))
+		assert.ErrorContains(t, err, "nope.yaml")
+	})
+
+	t.Run("same file extended twice", func(t *testing.T) {
+		cfg, err := ReadConfigFile(filepath.Join(dir, "diamond", "a.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, ptr(3), cfg.Indent)
+		assert.Equal(t, []string{".d"}, cfg.Gap)
+	})
+}
+
+func TestFormatConfig_Override(t *testing.T) {
+	cfg := FormatConfig{
+		Indent:    ptr(4),
+		Gap:       []string{"."},
+		Sort:      []string{".b"},
+		TrimLines: ptr(false),
+	}
+	flags := FormatConfig{
+		Gap:       []string{".a"},
+		Sort:      []string{},
+		TrimLines: ptr(true),
+	}
+
+	expected := FormatConfig{
+		Indent:    ptr(4),
+		Gap:       []string{".a"},
+		Sort:      []string{},
+		TrimLines: ptr(true),
+	}
+	assert.Equal(t, expected, cfg.Override(flags))
+}
+
+func TestFormatConfig_FormatOptions(t *testing.T) {
+	options := FormatConfig{}.FormatOptions()
+	assert.Equal(t, 2, options.EncodeOptions.Indent)
+	assert.True(t, options.FinalNewline)
+	assert.True(t, options.TrimTrailingWhitespace)
+
+	options = FormatConfig{Indent: ptr(4), Exclude: []string{"a/**"}}.FormatOptions()
+	assert.Equal(t, 4, options.EncodeOptions.Indent)
+	assert.Equal(t, []string{"a/**"}, options.DiscoveryOptions.Exclude)
+
+	options = FormatConfig{FinalNewline: ptr(false), TrimLines: ptr(false)}.FormatOptions()
+	assert.False(t, options.FinalNewline)
+	assert.False(t, options.TrimTrailingWhitespace)
+}
+
+func TestReadFormatConfig(t *testing.T) {
+	cfg, err := ReadFormatConfig(strings.NewReader(`indent: 4
+gap:
+  - .
+final-newline: false
+trim-lines: true
+`))
+	require.NoError(t, err)
+
+	expected := FormatConfig{
+		Indent:       ptr(4),
+		Gap:          []string{"."},
+		FinalNewline: ptr(false),
+		TrimLines:    ptr(true),
+	}
+	assert.Equal(t, expected, withoutSources(cfg))
+
+	cfg, err = ReadFormatConfig(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, FormatConfig{}, cfg)
+}
+
+func TestConfigLoader(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".yam.yaml":                  "indent: 4\ngap:\n  - .\n",
+		"project/.yam.yaml":          "gap:\n  - .a\n",
+		"project/nested/keep.txt":    "",
+		"standalone/.yam.yaml":       "root: true\nsort:\n  - .b\n",
+		"standalone/nested/keep.txt": "",
+	})
+
+	loader, err := NewConfigLoader(filepath.Join(dir, "project"), "")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		path     string
+		expected FormatConfig
+	}{
+		{
+			name:     "config in the file's directory and its parent",
+			path:     "a.yaml",
+			expected: FormatConfig{Indent: ptr(4), Gap: []string{".", ".a"}},
+		},
+		{
+			name:     "directory without a config",
+			path:     "nested/b.yaml",
+			expected: FormatConfig{Indent: ptr(4), Gap: []string{".", ".a"}},
+		},
+		{
+			name:     "root config",
+			path:     "../standalone/nested/c.yaml",
+			expected: FormatConfig{Root: true, Sort: []string{".b"}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loader.ConfigForFile(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, withoutSources(cfg))
+		})
+	}
+
+	t.Run("invalid config", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{
+			"broken/.yam.yaml": "indent: [\n",
+		})
+
+		_, err := loader.ConfigForFile("../broken/d.yaml")
+		assert.ErrorContains(t, err, "parsing yam config")
+	})
+}
+
+func TestConfigLoader_overrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".yam.yaml": `root: true
+indent: 2
+gap:
+  - .
+overrides:
+  - files:
+      - ".github/workflows/*.yaml"
+    gap:
+      - .jobs
+  - files:
+      - "*.k8s.yaml"
+    indent: 4
+`,
+		"sub/.yam.yaml": `overrides:
+  - files:
+      - "*.k8s.yaml"
+    indent: 8
+`,
+		"shared.yaml": `overrides:
+  - files:
+      - "only/*.yaml"
+    indent: 3
+`,
+	})
+
+	loader, err := NewConfigLoader(dir, "")
+	require.NoError(t, err)
+
+	cases := []struct {
+		path     string
+		expected FormatConfig
+	}{
+		{
+			path:     "a.yaml",
+			expected: FormatConfig{Root: true, Indent: ptr(2), Gap: []string{"."}},
+		},
+		{
+			path:     ".github/workflows/ci.yaml",
+			expected: FormatConfig{Root: true, Indent: ptr(2), Gap: []string{".", ".jobs"}},
+		},
+		{
+			path:     "sub/.github/workflows/ci.yaml",
+			expected: FormatConfig{Root: true, Indent: ptr(2), Gap: []string{"."}},
+		},
+		{
+			path:     "deploy/app.k8s.yaml",
+			expected: FormatConfig{Root: true, Indent: ptr(4), Gap: []string{"."}},
+		},
+		{
+			// Overrides from nearer configs are applied last.
+			path:     "sub/app.k8s.yaml",
+			expected: FormatConfig{Root: true, Indent: ptr(8), Gap: []string{"."}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.path, func(t *testing.T) {
+			cfg, err := loader.ConfigForFile(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, withoutSources(cfg))
+		})
+	}
+
+	t.Run("config file passed explicitly", func(t *testing.T) {
+		loader, err := NewConfigLoader(filepath.Join(dir, "sub"), filepath.Join(dir, "shared.yaml"))
+		require.NoError(t, err)
+
+		// The patterns are relative to the loader's directory.
+		cfg, err := loader.ConfigForFile("only/a.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, FormatConfig{Indent: ptr(3)}, withoutSources(cfg))
+	})
+
+	t.Run("invalid overrides", func(t *testing.T) {
+		_, err := readConfig(strings.NewReader("overrides:\n  - indent: 4\n"), "", dir)
+		assert.ErrorContains(t, err, "files must list at least one pattern")
+
+		_, err = readConfig(strings.NewReader("overrides:\n  - files: ['[']\n"), "", dir)
+		assert.ErrorContains(t, err, "invalid pattern")
+	})
+}
+
+func TestOptionsForFile(t *testing.T) {
+	options := testOptions
+	options.OptionsForFile = func(p string) (FormatOptions, error) {
+		opts := FormatConfig{Indent: ptr(4)}.FormatOptions()
+		if p == "two.yaml" {
+			opts.EncodeOptions.Indent = 2
+		}
+		return opts, nil
+	}
+
+	fsys := fstest.MapFS{
+		"four.yaml": {Data: []byte("a:\n    - b\n")},
+		"two.yaml":  {Data: []byte("a:\n  - b\n")},
+	}
+
+	results, err := LintFiles(fsys, nil, options)
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Passed())
+	assert.True(t, results[1].Passed())
+}
+
+// writeFiles creates the given files, with the given content, within dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestConfigLoader_sources(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".yam.yaml": `indent: 4
+gap:
+  - .
+overrides:
+  - files:
+      - "*.k8s.yaml"
+    gap:
+      - .k
+`,
+		"sub/.yam.yaml":    "extends:\n  - ../styles/base.yaml\ngap:\n  - .s\n",
+		"styles/base.yaml": "sort:\n  - .b\n",
+	})
+	t.Chdir(dir)
+
+	loader, err := NewConfigLoader(".", "")
+	require.NoError(t, err)
+
+	cfg, err := loader.ConfigForFile("sub/app.k8s.yaml")
+	require.NoError(t, err)
+
+	source, ok := cfg.Source("indent")
+	assert.True(t, ok)
+	assert.Equal(t, ".yam.yaml", source)
+
+	assert.Equal(t, []string{".yam.yaml", "sub/.yam.yaml", ".yam.yaml (override 1)"}, cfg.ItemSources("gap"))
+	assert.Equal(t, []string{"styles/base.yaml"}, cfg.ItemSources("sort"))
+
+	_, ok = cfg.Source("trim-lines")
+	assert.False(t, ok)
+
+	flags := FormatConfig{Gap: []string{".f"}}.WithSource("flags")
+	cfg = cfg.Override(flags)
+
+	source, _ = cfg.Source("gap")
+	assert.Equal(t, "flags", source)
+	assert.Equal(t, []string{"flags"}, cfg.ItemSources("gap"))
+}
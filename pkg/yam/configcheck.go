@@ -0,0 +1,389 @@
+package yam
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/glob"
+	"github.com/chainguard-dev/yam/pkg/yam/formatted/path"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError describes a problem with the content of a yam config file.
+type ConfigError struct {
+	// Path is the path of the config file, or empty if the config didn't come
+	// from a named file.
+	Path string
+
+	// Line and Column give the position of the problem in the file, starting at
+	// 1.
+	Line   int
+	Column int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Message)
+}
+
+// settingChecks has the checks for the values of config settings that can't be
+// expressed by their type alone. For list settings, the check is applied to
+// each item.
+var settingChecks = map[string]func(string) error{
+	"indent":  checkIndent,
+	"gap":     checkExpression,
+	"sort":    checkExpression,
+	"quote":   checkExpression,
+	"dedup":   checkExpression,
+	"include": checkPattern,
+	"exclude": checkPattern,
+	"files":   checkPattern,
+}
+
+// MinIndent and MaxIndent are the smallest and largest indents that files can
+// be formatted with.
+const (
+	MinIndent = 2
+	MaxIndent = 9
+)
+
+func checkIndent(value string) error {
+	var indent int
+	if err := yaml.Unmarshal([]byte(value), &indent); err != nil || indent < MinIndent || indent > MaxIndent {
+		return fmt.Errorf("must be between %d and %d, got %s", MinIndent, MaxIndent, value)
+	}
+
+	return nil
+}
+
+func checkExpression(expr string) error {
+	if _, err := path.Parse(expr); err != nil {
+		return fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+
+	return nil
+}
+
+func checkPattern(pattern string) error {
+	if err := glob.Validate(pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return nil
+}
+
+// checkConfig checks a config file's YAML document against the settings of
+// FormatConfig, and returns a ConfigError for every problem it finds: unknown
+// or repeated settings, values of the wrong type, and invalid path expressions
+// or glob patterns. name is used as the ConfigErrors' Path.
+func checkConfig(doc *yaml.Node, name string) error {
+	c := &configChecker{name: name}
+	c.check("", reflect.TypeFor[FormatConfig](), documentRoot(doc))
+
+	return errors.Join(c.problems...)
+}
+
+type configChecker struct {
+	name     string
+	problems []error
+}
+
+// addf records a problem at the position of the given node. setting, if not
+// empty, is the name of the setting the problem is with.
+func (c *configChecker) addf(n *yaml.Node, setting, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if setting != "" {
+		msg = setting + ": " + msg
+	}
+
+	c.problems = append(c.problems, &ConfigError{
+		Path:    c.name,
+		Line:    n.Line,
+		Column:  n.Column,
+		Message: msg,
+	})
+}
+
+// check checks that the node n, the value of the given setting, can be decoded
+// into a value of type t.
+func (c *configChecker) check(setting string, t reflect.Type, n *yaml.Node) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	if n.Tag == resetTag {
+		switch {
+		case setting == "extends":
+			c.addf(n, setting, "%s can't be used here, since extends isn't merged", resetTag)
+			return
+		case t.Kind() != reflect.Slice:
+			c.addf(n, setting, "%s can only be used on lists", resetTag)
+			return
+		case n.Kind == yaml.ScalarNode && n.Value == "":
+			// This resets the list to be empty.
+			return
+		}
+	}
+
+	// YAML reads a plain value that starts with "!", like a negated path
+	// expression, as a tag.
+	if isLocalTag(n.Tag) && n.Tag != resetTag {
+		c.addf(n, setting, "unknown tag %q (values that start with \"!\" need to be quoted)", n.Tag)
+		return
+	}
+
+	// A null value leaves the setting unset.
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		return
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!bool" {
+			c.addf(n, setting, "expected true or false, got %s", describeNode(n))
+		}
+
+	case reflect.Int:
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!int" {
+			c.addf(n, setting, "expected an integer, got %s", describeNode(n))
+			return
+		}
+
+		c.checkValue(setting, n)
+
+	case reflect.String:
+		if n.Kind != yaml.ScalarNode {
+			c.addf(n, setting, "expected a string, got %s", describeNode(n))
+			return
+		}
+
+		c.checkValue(setting, n)
+
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			c.addf(n, setting, "expected a list, got %s", describeNode(n))
+			return
+		}
+
+		for _, item := range n.Content {
+			if item.Kind == yaml.ScalarNode && item.ShortTag() == "!!null" {
+				c.addf(item, setting, "list items can't be empty")
+				continue
+			}
+
+			c.check(setting, t.Elem(), item)
+		}
+
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			c.addf(n, setting, "expected a mapping, got %s", describeNode(n))
+			return
+		}
+
+		c.checkMapping(setting, t, n)
+
+	default:
+		panic(fmt.Sprintf("unexpected config field type %s", t))
+	}
+}
+
+// checkValue applies the setting's check from settingChecks, if it has one, to
+// the scalar node n.
+func (c *configChecker) checkValue(setting string, n *yaml.Node) {
+	if check, ok := settingChecks[setting]; ok {
+		if err := check(n.Value); err != nil {
+			c.addf(n, setting, "%v", err)
+		}
+	}
+}
+
+// checkMapping checks the keys and values of a mapping node that's decoded into
+// the struct type t.
+func (c *configChecker) checkMapping(setting string, t reflect.Type, n *yaml.Node) {
+	fields := configFields(t)
+	seen := make(map[string]bool)
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		field, ok := fields[key.Value]
+		if !ok {
+			msg := fmt.Sprintf("unknown setting %q", key.Value)
+			if suggestion := closestName(key.Value, fields); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			c.addf(key, setting, "%s", msg)
+			continue
+		}
+
+		if seen[key.Value] {
+			c.addf(key, setting, "%q is set more than once", key.Value)
+			continue
+		}
+		seen[key.Value] = true
+
+		c.check(key.Value, field.Type, value)
+	}
+
+	if t == reflect.TypeFor[ConfigOverride]() && !hasItems(n, "files") {
+		c.addf(n, setting, "files must list at least one pattern")
+	}
+}
+
+// configFields returns the fields of a config struct type, by their YAML names.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		fields[name] = f
+	}
+
+	return fields
+}
+
+// hasItems reports whether the mapping node n has a non-empty list as the value
+// of the given key.
+func hasItems(n *yaml.Node, key string) bool {
+	value := mappingValue(n, key)
+	return value != nil && value.Kind == yaml.SequenceNode && len(value.Content) > 0
+}
+
+// documentRoot returns the root node of the given document node.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+
+	return doc
+}
+
+// mappingValue returns the value of the given key in the mapping node n, or nil
+// if it's not set.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			value := n.Content[i+1]
+			if value.Kind == yaml.AliasNode {
+				value = value.Alias
+			}
+
+			return value
+		}
+	}
+
+	return nil
+}
+
+// resetTag is the YAML tag for a list that replaces the list it's merged with,
+// rather than being appended to it.
+const resetTag = "!reset"
+
+// takeResetTags returns the settings in the mapping node n whose values are
+// tagged with !reset, and removes the tags, so that the values can be decoded
+// as plain lists. An empty value tagged with !reset becomes an empty list.
+func takeResetTags(n *yaml.Node) []string {
+	var settings []string
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if value.Tag != resetTag {
+			continue
+		}
+
+		settings = append(settings, key.Value)
+
+		if value.Kind == yaml.ScalarNode {
+			value.Kind = yaml.SequenceNode
+			value.Value = ""
+		}
+		value.Tag = "!!seq"
+	}
+
+	return settings
+}
+
+// isLocalTag reports whether the tag is a local tag, like !reset, rather than
+// one of YAML's own tags, like !!str.
+func isLocalTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+// describeNode describes a node's value for error messages.
+func describeNode(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", n.Value)
+	}
+}
+
+// closestName returns the name that's most likely to have been meant instead of
+// the unknown name, or an empty string if none of them are close enough.
+func closestName(unknown string, names map[string]reflect.StructField) string {
+	const maxDistance = 2
+
+	var closest string
+	best := maxDistance + 1
+
+	for name := range names {
+		d := editDistance(strings.ToLower(unknown), name)
+		if d < best || (d == best && name < closest) {
+			closest, best = name, d
+		}
+	}
+
+	if best > maxDistance {
+		return ""
+	}
+
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,140 @@
+package yam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/yam/pkg/yam/formatted"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readConfigChecks(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name:   "valid config",
+			config: "indent: 4\ngap: [.]\nfinal-newline: false\nexclude: ['testdata/**']\noverrides:\n  - files: ['*.k8s.yaml']\n    sort: [.b]\n",
+		},
+		{
+			name:   "null values",
+			config: "indent:\ngap:\n",
+		},
+		{
+			name:     "unknown setting",
+			config:   "indent: 2\ngaps:\n  - .\n",
+			expected: []string{`.yam.yaml:2:1: unknown setting "gaps" (did you mean "gap"?)`},
+		},
+		{
+			name:     "unknown setting without a suggestion",
+			config:   "colour: red\n",
+			expected: []string{`.yam.yaml:1:1: unknown setting "colour"`},
+		},
+		{
+			name:   "wrong types",
+			config: "indent: four\ngap: .\nsort: [[.a]]\nroot: yes please\noverrides: {}\n",
+			expected: []string{
+				`.yam.yaml:1:9: indent: expected an integer, got "four"`,
+				`.yam.yaml:2:6: gap: expected a list, got "."`,
+				`.yam.yaml:3:8: sort: expected a string, got a list`,
+				`.yam.yaml:4:7: root: expected true or false, got "yes please"`,
+				`.yam.yaml:5:12: overrides: expected a list, got a mapping`,
+			},
+		},
+		{
+			name:   "invalid expressions and patterns",
+			config: "gap:\n  - .a\n  - .b[\ninclude: ['[']\n",
+			expected: []string{
+				`.yam.yaml:3:5: gap: invalid expression ".b[": expression not supported`,
+				`.yam.yaml:4:11: include: invalid pattern "[": syntax error in pattern`,
+			},
+		},
+		{
+			name:   "indent out of range",
+			config: "indent: -1\noverrides:\n  - files: ['*.k8s.yaml']\n    indent: 10\n",
+			expected: []string{
+				`.yam.yaml:1:9: indent: must be between 2 and 9, got -1`,
+				`.yam.yaml:4:13: indent: must be between 2 and 9, got 10`,
+			},
+		},
+		{
+			name:     "repeated setting",
+			config:   "indent: 2\nindent: 4\n",
+			expected: []string{`.yam.yaml:2:1: "indent" is set more than once`},
+		},
+		{
+			name:   "problems in overrides",
+			config: "overrides:\n  - files: ['*.yaml']\n    recursive: true\n  - quote: ['.a[']\n",
+			expected: []string{
+				`.yam.yaml:3:5: overrides: unknown setting "recursive"`,
+				`.yam.yaml:4:13: quote: invalid expression ".a[": expression not supported`,
+				`.yam.yaml:4:5: overrides: files must list at least one pattern`,
+			},
+		},
+		{
+			name:   "reset tags",
+			config: "gap: !reset\nsort: !reset [.a]\noverrides:\n  - files: [a.yaml]\n    quote: !reset []\n",
+		},
+		{
+			name:     "unquoted negated expression",
+			config:   "gap:\n  - .pipeline[]\n  - !.pipeline[0]\n  - \"!.pipeline[1]\"\n",
+			expected: []string{`.yam.yaml:3:5: gap: unknown tag "!.pipeline[0]" (values that start with "!" need to be quoted)`},
+		},
+		{
+			name:   "misplaced reset tags",
+			config: "indent: !reset 4\nextends: !reset [base.yaml]\ngap: [!reset .a]\n",
+			expected: []string{
+				`.yam.yaml:1:9: indent: !reset can only be used on lists`,
+				`.yam.yaml:2:10: extends: !reset can't be used here, since extends isn't merged`,
+				`.yam.yaml:3:7: gap: !reset can only be used on lists`,
+			},
+		},
+		{
+			name:     "empty list item",
+			config:   "overrides:\n  -\n",
+			expected: []string{`.yam.yaml:2:4: overrides: list items can't be empty`},
+		},
+		{
+			name:     "not a mapping",
+			config:   "- indent: 2\n",
+			expected: []string{`.yam.yaml:1:1: expected a mapping, got a list`},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readConfig(strings.NewReader(tt.config), ".yam.yaml", t.TempDir())
+			if len(tt.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Equal(t, strings.Join(tt.expected, "\n"), err.Error())
+
+			var cfgErr *ConfigError
+			assert.ErrorAs(t, err, &cfgErr)
+		})
+	}
+}
+
+func TestReadFormatConfig_invalid(t *testing.T) {
+	_, err := ReadFormatConfig(strings.NewReader("sorts: [.a]\n"))
+	assert.EqualError(t, err, `line 1, column 1: unknown setting "sorts" (did you mean "sort"?)`)
+}
+
+// formatted.ReadConfigFrom has its own list of config settings, since it can't
+// use FormatConfig.
+func TestReadConfigFrom_knowsEverySetting(t *testing.T) {
+	var config strings.Builder
+	for name := range configFields(reflect.TypeFor[FormatConfig]()) {
+		config.WriteString(name + ":\n")
+	}
+
+	_, err := formatted.ReadConfigFrom(strings.NewReader(config.String()))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,115 @@
+package yam
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+)
+
+// untrackedSettings are the config settings whose sources aren't recorded,
+// because they don't end up in FormatOptions.
+var untrackedSettings = []string{"root", "extends", "overrides", "files"}
+
+// valueSource records where the value of a config setting came from.
+type valueSource struct {
+	// set is the source that set the value last.
+	set string
+
+	// items has the source of each item of a list value.
+	items []string
+}
+
+// configSources maps config settings to where their values came from.
+type configSources map[string]valueSource
+
+// sourcesOf returns the sources for the settings that are set in v, which is a
+// FormatConfig or a ConfigOverride, recording that they all came from source.
+func sourcesOf(v any, source string) configSources {
+	rv := reflect.ValueOf(v)
+
+	var sources configSources
+	for setting, f := range configFields(rv.Type()) {
+		fv := rv.FieldByIndex(f.Index)
+		if slices.Contains(untrackedSettings, setting) || fv.IsNil() {
+			continue
+		}
+
+		src := valueSource{set: source}
+		if fv.Kind() == reflect.Slice {
+			src.items = slices.Repeat([]string{source}, fv.Len())
+		}
+
+		if sources == nil {
+			sources = make(configSources)
+		}
+		sources[setting] = src
+	}
+
+	return sources
+}
+
+// merge returns the sources for the result of applying a config with the other
+// sources on top of a config with these sources. appendItems reports whether
+// the other config's list for a setting is appended to this config's list,
+// rather than replacing it.
+func (s configSources) merge(other configSources, appendItems func(setting string) bool) configSources {
+	if len(other) == 0 {
+		return s
+	}
+
+	merged := make(configSources, len(s)+len(other))
+	maps.Copy(merged, s)
+
+	for setting, src := range other {
+		if src.items != nil && appendItems(setting) {
+			src.items = append(slices.Clip(s[setting].items), src.items...)
+		}
+
+		merged[setting] = src
+	}
+
+	return merged
+}
+
+// Source returns where the value of the given setting came from. The setting
+// is named as in a config file, e.g. "indent". The source is the path of the
+// config file that set the value, relative to the current working directory
+// when possible, or the source given to WithSource. It returns false if the
+// setting isn't set, so that its default value is used.
+func (c FormatConfig) Source(setting string) (string, bool) {
+	src, ok := c.sources[setting]
+	return src.set, ok
+}
+
+// ItemSources returns where each item of the given list setting came from, in
+// the same way as Source. Lists from several configs can be merged, so their
+// items can come from different places.
+func (c FormatConfig) ItemSources(setting string) []string {
+	return c.sources[setting].items
+}
+
+// WithSource returns the config with source recorded as where each of the
+// settings set in it came from. It's meant for configs that don't come from a
+// config file, such as one made from CLI flags.
+func (c FormatConfig) WithSource(source string) FormatConfig {
+	c.sources = sourcesOf(c, source)
+	return c
+}
+
+// displayPath returns the path to show for the file at the given absolute
+// path, which is relative to the current working directory if possible.
+func displayPath(abs string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return abs
+	}
+
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		return abs
+	}
+
+	return rel
+}
@@ -5,10 +5,59 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+
+	"github.com/chainguard-dev/yam/pkg/diff"
 )
 
 type DiffHandler func(want, got []byte) error
 
+// diffContextLines is the number of unchanged lines shown around each change
+// in a diff.
+const diffContextLines = 5
+
+// UnifiedDiff is a DiffHandler that writes a unified diff from "got" (the
+// current file contents) to "want" (the correctly formatted output) to stderr.
+// The output is colored when stderr is a terminal, unless the NO_COLOR
+// environment variable is set.
+func UnifiedDiff(want, got []byte) error {
+	handlerOutput := os.Stderr
+
+	// As with ExecDiff, removed lines are what's there now and added lines are
+	// the fix, so the output can be applied as a patch to format the file.
+	err := diff.WriteUnified(handlerOutput, got, want, diff.UnifiedOptions{
+		OldLabel: "got",
+		NewLabel: "want",
+		Context:  diffContextLines,
+		Color:    useColor(handlerOutput),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(handlerOutput, "\n") // for gap between diffs; useful if diffing multiple files.
+
+	return nil
+}
+
+// useColor reports whether output written to f should be colored.
+func useColor(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// ExecDiff is a DiffHandler that runs the external `diff` command.
+//
+// Deprecated: ExecDiff requires a `diff` binary to be installed. Use
+// UnifiedDiff instead.
 func ExecDiff(want, got []byte) error {
 	handlerOutput := os.Stderr
 
@@ -48,7 +97,7 @@ func ExecDiff(want, got []byte) error {
 	cmd := exec.Command(
 		command,
 		"-U",
-		"5",
+		strconv.Itoa(diffContextLines),
 		"--label",
 		"got",
 		"--label",
@@ -0,0 +1,188 @@
+package yam
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/chainguard-dev/yam/pkg/glob"
+	"github.com/chainguard-dev/yam/pkg/util"
+)
+
+// defaultExcludes are patterns for directories that are always skipped when
+// looking for files within a directory.
+var defaultExcludes = []string{
+	"**/.git",
+	"**/vendor",
+}
+
+// findFiles returns the paths of the YAML files referenced by the given paths,
+// in order and without duplicates. Files are included as given, while
+// directories are searched for files according to the given options.
+func findFiles(fsys fs.FS, paths []string, options DiscoveryOptions) ([]string, error) {
+	// "No paths" means "look at all files in the current directory".
+	if len(paths) == 0 {
+		paths = append(paths, ".")
+	}
+
+	if err := validatePatterns(options); err != nil {
+		return nil, err
+	}
+
+	var ignores *ignoreMatcher
+	if !options.NoIgnore {
+		ignores = newIgnoreMatcher(fsys)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	addFile := func(p string) {
+		if !util.IsYAML(p) || seen[p] {
+			return
+		}
+		seen[p] = true
+		files = append(files, p)
+	}
+
+	for _, p := range paths {
+		p = cleanPath(p)
+
+		stat, err := fs.Stat(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat %q: %w", p, err)
+		}
+
+		if !stat.IsDir() {
+			addFile(p)
+			continue
+		}
+
+		dirFiles, err := findFilesInDir(fsys, p, options, ignores)
+		if err != nil {
+			return nil, fmt.Errorf("unable to search directory %q: %w", p, err)
+		}
+
+		for _, f := range dirFiles {
+			addFile(f)
+		}
+	}
+
+	return files, nil
+}
+
+// isSkipped reports whether the file at the given path would be skipped if it
+// were found while searching a directory, because it or one of its parent
+// directories is excluded, ignored or not included.
+func isSkipped(fsys fs.FS, p string, options DiscoveryOptions) (bool, error) {
+	if err := validatePatterns(options); err != nil {
+		return false, err
+	}
+
+	p = cleanPath(p)
+
+	for _, dir := range parentDirs(p)[1:] {
+		if isExcluded(dir, options) {
+			return true, nil
+		}
+	}
+
+	if isExcluded(p, options) || !isIncluded(p, options) {
+		return true, nil
+	}
+
+	if options.NoIgnore {
+		return false, nil
+	}
+
+	return newIgnoreMatcher(fsys).isIgnored(p)
+}
+
+// findFilesInDir returns the paths of files within the given directory that
+// aren't excluded by the given options or ignore files. Ignore files are
+// disregarded if ignores is nil.
+func findFilesInDir(fsys fs.FS, dirPath string, options DiscoveryOptions, ignores *ignoreMatcher) ([]string, error) {
+	var files []string
+
+	err := fs.WalkDir(fsys, dirPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && p == dirPath {
+			return nil
+		}
+
+		skip := isExcluded(p, options)
+		if !skip && ignores != nil {
+			// Any ignored parent directories have already been skipped by the walk.
+			skip, err = ignores.matches(p, d.IsDir())
+			if err != nil {
+				return err
+			}
+		}
+
+		if d.IsDir() {
+			if !options.Recursive || skip {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if skip || !d.Type().IsRegular() || !isIncluded(p, options) {
+			return nil
+		}
+
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func isExcluded(p string, options DiscoveryOptions) bool {
+	// Patterns have already been validated, so errors can be ignored.
+	excluded, _ := glob.MatchAny(defaultExcludes, p)
+	if excluded {
+		return true
+	}
+
+	excluded, _ = glob.MatchAny(options.Exclude, p)
+	return excluded
+}
+
+func isIncluded(p string, options DiscoveryOptions) bool {
+	if len(options.Include) == 0 {
+		return true
+	}
+
+	included, _ := glob.MatchAny(options.Include, p)
+	return included
+}
+
+func validatePatterns(options DiscoveryOptions) error {
+	var errs []error
+
+	for _, p := range options.Include {
+		if err := glob.Validate(p); err != nil {
+			errs = append(errs, fmt.Errorf("invalid include pattern %q: %w", p, err))
+		}
+	}
+
+	for _, p := range options.Exclude {
+		if err := glob.Validate(p); err != nil {
+			errs = append(errs, fmt.Errorf("invalid exclude pattern %q: %w", p, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// cleanPath returns the given path in the form used by fs.FS.
+func cleanPath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
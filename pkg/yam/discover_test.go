@@ -0,0 +1,76 @@
+package yam
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_findFiles(t *testing.T) {
+	cases := []struct {
+		name      string
+		paths     []string
+		options   DiscoveryOptions
+		expected  []string
+		assertErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "current directory only",
+			expected:  []string{"a.yaml"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "recursive",
+			options:   DiscoveryOptions{Recursive: true},
+			expected:  []string{"a.yaml", "generated/g.yaml", "nested/b.yaml", "nested/deeper/c.yaml"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:  "recursive with include",
+			paths: []string{"nested"},
+			options: DiscoveryOptions{
+				Recursive: true,
+				Include:   []string{"**/deeper/*.yaml"},
+			},
+			expected:  []string{"nested/deeper/c.yaml"},
+			assertErr: assert.NoError,
+		},
+		{
+			name: "recursive with exclude",
+			options: DiscoveryOptions{
+				Recursive: true,
+				Exclude:   []string{"generated", "**/deeper/**"},
+			},
+			expected:  []string{"a.yaml", "nested/b.yaml"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:  "explicit files bypass patterns and are deduplicated",
+			paths: []string{"generated/g.yaml", "vendor/v.yaml", "generated"},
+			options: DiscoveryOptions{
+				Exclude: []string{"generated/**"},
+			},
+			expected:  []string{"generated/g.yaml", "vendor/v.yaml"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "invalid pattern",
+			options:   DiscoveryOptions{Include: []string{"[a-"}},
+			assertErr: assert.Error,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := os.DirFS("testdata/dir-scenario-2")
+
+			// The fixture's expected output files aren't of interest here.
+			tt.options.Exclude = append(tt.options.Exclude, "**/*_expected.yaml")
+
+			files, err := findFiles(fsys, tt.paths, tt.options)
+			tt.assertErr(t, err)
+			assert.Equal(t, tt.expected, files)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package yam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileError is an error that occurred while processing a single file.
+type FileError struct {
+	// Path is the path of the file that couldn't be processed.
+	Path string
+
+	Err error
+}
+
+func (e *FileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors is returned when one or more files couldn't be processed. It
+// holds an error for each of those files, in the order the files were found.
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	lines := make([]string, 0, len(e))
+	for _, fe := range e {
+		lines = append(lines, "  "+fe.Error())
+	}
+
+	return fmt.Sprintf("unable to process %d files:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// Unwrap returns the error for each file, so that errors.Is and errors.As can
+// match any of them.
+func (e FileErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, fe := range e {
+		errs = append(errs, fe)
+	}
+
+	return errs
+}
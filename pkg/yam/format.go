@@ -1,7 +1,9 @@
 package yam
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/fs"
 	"path/filepath"
 
@@ -9,91 +11,141 @@ import (
 	"github.com/chainguard-dev/yam/pkg/util"
 )
 
-func Format(fsys rwfs.FS, paths []string, options FormatOptions) error {
-	// "No paths" means "look at all files in the current directory".
-	if len(paths) == 0 {
-		paths = append(paths, ".")
-	}
+// stdinDisplayName is used in messages to refer to input that didn't come from
+// a named file.
+const stdinDisplayName = "<stdin>"
 
-	for _, p := range paths {
-		stat, err := fs.Stat(fsys, p)
-		if err != nil {
-			return fmt.Errorf("unable to stat %q: %w", p, err)
-		}
+// FormatResult describes the outcome of formatting a single file.
+type FormatResult struct {
+	// Path is the path of the formatted file.
+	Path string
+
+	// Changed reports whether formatting changed the file's content. Files
+	// whose content didn't change aren't written to.
+	Changed bool
+}
 
-		if stat.IsDir() {
-			errDir := formatDir(fsys, p, options)
-			if errDir != nil {
-				return fmt.Errorf("unable to format directory %q: %w", p, errDir)
-			}
+// Format formats the YAML files referenced by the given paths in place. See
+// FormatFiles for how errors for individual files are handled.
+func Format(fsys rwfs.FS, paths []string, options FormatOptions) error {
+	_, err := FormatFiles(fsys, paths, options)
+	return err
+}
 
-			continue
-		}
+// FormatFiles formats the YAML files referenced by the given paths, and
+// returns a result for each file, regardless of whether its content changed.
+// Files are formatted concurrently, as configured by the options' Jobs field,
+// but the results are in the order the files were found.
+//
+// Unless the options' FailFast field is set, a file that can't be formatted
+// doesn't stop the other files from being formatted. Instead, a FileErrors is
+// returned once every file has been processed, along with the results for the
+// files that were formatted. With FailFast, the *FileError for the first file
+// that can't be formatted is returned, along with the results for the files
+// that were formatted before it.
+func FormatFiles(fsys rwfs.FS, paths []string, options FormatOptions) ([]FormatResult, error) {
+	files, err := findFiles(fsys, paths, options.DiscoveryOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return processFiles(files, options.Jobs, options.FailFast, func(p string) (*FormatResult, error) {
+		return formatSingleFile(fsys, p, options)
+	})
+}
 
-		err = formatSingleFile(fsys, p, options)
+// FormatReader reads YAML from r and writes it to w, formatted according to
+// the given options.
+//
+// If name is non-empty, it's used as the path of the input within fsys, to
+// decide whether the input should be skipped because of ignore files or the
+// options' include and exclude patterns. Input that's skipped is written to w
+// unchanged.
+func FormatReader(fsys fs.FS, name string, r io.Reader, w io.Writer, options FormatOptions) error {
+	if name != "" {
+		skip, err := isSkipped(fsys, name, options.DiscoveryOptions)
 		if err != nil {
 			return err
 		}
+		if skip {
+			_, err = io.Copy(w, r)
+			return err
+		}
 	}
 
-	return nil
-}
-
-func formatDir(fsys rwfs.FS, dirPath string, options FormatOptions) error {
-	dirEntries, err := fs.ReadDir(fsys, dirPath)
+	options, err := options.forFile(name)
 	if err != nil {
 		return err
 	}
 
-	for _, file := range dirEntries {
-		if !file.Type().IsRegular() {
-			continue
-		}
-
-		p := filepath.Join(dirPath, file.Name())
-		errsingleFile := formatSingleFile(fsys, p, options)
-		if errsingleFile != nil {
-			return errsingleFile
+	formatted, err := applyFormatting(r, options)
+	if err != nil {
+		displayName := name
+		if displayName == "" {
+			displayName = stdinDisplayName
 		}
+		return fmt.Errorf("unable to format %q: %w", displayName, err)
 	}
 
-	return nil
+	_, err = formatted.WriteTo(w)
+	return err
 }
 
-func formatSingleFile(fsys rwfs.FS, path string, options FormatOptions) error {
+func formatSingleFile(fsys rwfs.FS, path string, options FormatOptions) (*FormatResult, error) {
 	// Immediately skip files that aren't YAML files
 	if !util.IsYAML(path) {
-		return nil
+		return nil, nil
 	}
 
-	p := filepath.Clean(path)
-	file, err := fsys.Open(p)
+	options, err := options.forFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer file.Close()
+	p := filepath.Clean(path)
 
-	formatted, err := applyFormatting(file, options)
+	// Read the whole file now, rather than formatting it as it's read, since
+	// some platforms don't allow replacing open files.
+	original, err := fs.ReadFile(fsys, p)
 	if err != nil {
-		return fmt.Errorf("unable to format %q: %w", path, err)
+		return nil, err
 	}
 
-	err = fsys.Truncate(p, 0)
-	if err != nil {
-		return err
+	result := &FormatResult{
+		Path: path,
 	}
-	writeableFile, err := fsys.OpenRW(p)
-	if err != nil {
-		return err
+
+	var cacheOpts []byte
+	if options.Cache != nil {
+		cacheOpts, err = cacheOptions(options)
+		if err != nil {
+			return nil, err
+		}
+
+		if options.Cache.Contains(original, cacheOpts) {
+			return result, nil
+		}
 	}
 
-	_, err = formatted.WriteTo(writeableFile)
+	formatted, err := applyFormatting(bytes.NewReader(original), options)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to format %q: %w", path, err)
 	}
 
-	_ = writeableFile.Close()
+	result.Changed = !bytes.Equal(formatted.Bytes(), original)
+	if !result.Changed {
+		if options.Cache != nil {
+			// The cache is only an optimization, so failing to update it is fine.
+			_ = options.Cache.Add(original, cacheOpts)
+		}
+
+		return result, nil
+	}
+
+	err = rwfs.WriteFile(fsys, p, formatted.Bytes())
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return result, nil
 }
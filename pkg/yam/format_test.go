@@ -1,7 +1,10 @@
 package yam
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/chainguard-dev/yam/pkg/rwfs/tester"
 	"github.com/chainguard-dev/yam/pkg/yam/formatted"
@@ -59,6 +62,9 @@ func Test_formatSingleFile(t *testing.T) {
 		{
 			fixture: "testdata/format/quotes.yaml",
 		},
+		{
+			fixture: "testdata/format/multidoc.yaml",
+		},
 	}
 
 	for _, tt := range cases {
@@ -66,7 +72,7 @@ func Test_formatSingleFile(t *testing.T) {
 			fsys, err := tester.NewFS(tt.fixture)
 			require.NoError(t, err)
 
-			err = formatSingleFile(fsys, tt.fixture, testOptions)
+			_, err = formatSingleFile(fsys, tt.fixture, testOptions)
 			assert.NoError(t, err)
 
 			if diff := fsys.Diff(tt.fixture); diff != "" {
@@ -81,7 +87,7 @@ func Test_formatSingleFileWithDedup(t *testing.T) {
 		fsys, err := tester.NewFS("testdata/format/dedup.yaml")
 		require.NoError(t, err)
 
-		err = formatSingleFile(fsys, "testdata/format/dedup.yaml", testOptionsWithDedup)
+		_, err = formatSingleFile(fsys, "testdata/format/dedup.yaml", testOptionsWithDedup)
 		assert.NoError(t, err)
 
 		if diff := fsys.Diff("testdata/format/dedup.yaml"); diff != "" {
@@ -90,26 +96,78 @@ func Test_formatSingleFileWithDedup(t *testing.T) {
 	})
 }
 
+func Test_formatSingleFileChanged(t *testing.T) {
+	cases := []struct {
+		fixture string
+		changed bool
+	}{
+		{
+			fixture: "testdata/format/simple.yaml",
+			changed: true,
+		},
+		{
+			fixture: "testdata/format/unchanged.yaml",
+			changed: false,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.fixture, func(t *testing.T) {
+			fsys, err := tester.NewFS(tt.fixture)
+			require.NoError(t, err)
+
+			result, err := formatSingleFile(fsys, tt.fixture, testOptions)
+			require.NoError(t, err)
+
+			assert.Equal(t, &FormatResult{Path: tt.fixture, Changed: tt.changed}, result)
+
+			// Files are only written to if their content changed.
+			assert.Equal(t, tt.changed, fsys.Written(tt.fixture))
+
+			if diff := fsys.Diff(tt.fixture); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
 func TestFormat(t *testing.T) {
 	cases := []struct {
-		name  string
-		paths []string
+		name      string
+		fixture   string
+		paths     []string
+		discovery DiscoveryOptions
 	}{
 		{
-			name: "multiple files and dirs",
+			name:    "multiple files and dirs",
+			fixture: "testdata/dir-scenario-1",
 			paths: []string{
 				"testdata/dir-scenario-1/a.yaml",
 				"testdata/dir-scenario-1/subdir2",
 			},
 		},
+		{
+			name:    "recursive with excludes",
+			fixture: "testdata/dir-scenario-2",
+			paths: []string{
+				"testdata/dir-scenario-2",
+			},
+			discovery: DiscoveryOptions{
+				Recursive: true,
+				Exclude:   []string{"**/generated"},
+			},
+		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			fsys, err := tester.NewFS("testdata/dir-scenario-1")
+			fsys, err := tester.NewFS(tt.fixture)
 			require.NoError(t, err)
 
-			err = Format(fsys, tt.paths, testOptions)
+			options := testOptions
+			options.DiscoveryOptions = tt.discovery
+
+			err = Format(fsys, tt.paths, options)
 			require.NoError(t, err)
 
 			if diff := fsys.DiffAll(); diff != "" {
@@ -118,3 +176,82 @@ func TestFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatReader(t *testing.T) {
+	fsys := fstest.MapFS{
+		".yamignore": {Data: []byte("ignored/\n")},
+	}
+
+	cases := []struct {
+		name     string
+		fileName string
+		input    string
+		expected string
+	}{
+		{
+			name:     "unnamed input",
+			input:    "a:   1\nb: 2\n---\nc: 3",
+			expected: "a: 1\n\nb: 2\n---\nc: 3\n",
+		},
+		{
+			name:     "named input",
+			fileName: "dir/file.yaml",
+			input:    "a:   1\nb: 2",
+			expected: "a: 1\n\nb: 2\n",
+		},
+		{
+			name:     "ignored input is left unchanged",
+			fileName: "ignored/file.yaml",
+			input:    "a:   1\nb: 2",
+			expected: "a:   1\nb: 2",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			err := FormatReader(fsys, tt.fileName, strings.NewReader(tt.input), out, testOptions)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestFormatFiles_errors(t *testing.T) {
+	const fixture = "testdata/dir-scenario-errors"
+
+	t.Run("errors are collected", func(t *testing.T) {
+		fsys, err := tester.NewFS(fixture)
+		require.NoError(t, err)
+
+		results, err := FormatFiles(fsys, []string{fixture}, testOptions)
+
+		var fileErrs FileErrors
+		require.ErrorAs(t, err, &fileErrs)
+		require.Len(t, fileErrs, 1)
+		assert.Equal(t, "testdata/dir-scenario-errors/a.yaml", fileErrs[0].Path)
+
+		// The file after the broken one is still formatted.
+		assert.Equal(t, []FormatResult{{Path: "testdata/dir-scenario-errors/b.yaml", Changed: true}}, results)
+		if diff := fsys.DiffAll(); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		fsys, err := tester.NewFS(fixture)
+		require.NoError(t, err)
+
+		options := testOptions
+		options.FailFast = true
+		options.Jobs = 1
+
+		_, err = FormatFiles(fsys, []string{fixture}, options)
+
+		var fileErr *FileError
+		require.ErrorAs(t, err, &fileErr)
+		assert.Equal(t, "testdata/dir-scenario-errors/a.yaml", fileErr.Path)
+		assert.False(t, fsys.Written("testdata/dir-scenario-errors/b.yaml"))
+	})
+}
@@ -3,6 +3,7 @@ package formatted
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -57,6 +58,10 @@ type Encoder struct {
 	sortPaths  []path.Path
 	quotePaths []path.Path
 	dedupPaths []path.Path
+
+	// documentCount tracks how many documents have been written by Encode, so
+	// that subsequent documents can be separated with a document start marker.
+	documentCount *int
 }
 
 // NewEncoder returns a new encoder that can write formatted YAML to the given
@@ -66,9 +71,10 @@ func NewEncoder(w io.Writer) Encoder {
 	yamlEnc.SetIndent(defaultIndentSize)
 
 	enc := Encoder{
-		w:          w,
-		yamlEnc:    yamlEnc,
-		indentSize: defaultIndentSize,
+		w:             w,
+		yamlEnc:       yamlEnc,
+		indentSize:    defaultIndentSize,
+		documentCount: new(int),
 	}
 
 	return enc
@@ -77,6 +83,11 @@ func NewEncoder(w io.Writer) Encoder {
 // AutomaticConfig configures the encoder using a `.yam.yaml` config file in the
 // current working directory, if one exists. This method is meant to work on a
 // "best effort" basis, and all errors are silently ignored.
+//
+// Deprecated: AutomaticConfig only reads the encoder settings of the config
+// file in the current working directory, in the way ReadConfig does. Use
+// yam.ConfigLoader to find the config for a file, and its FormatOptions to
+// configure an encoder with UseOptions.
 func (enc Encoder) AutomaticConfig() Encoder {
 	options, err := ReadConfig()
 	if err != nil {
@@ -96,6 +107,10 @@ func (enc Encoder) AutomaticConfig() Encoder {
 // ReadConfig tries to load a yam encoder config from a `.yam.yaml` file in the
 // current working directory. It returns an error if it wasn't able to open or
 // unmarshal the file.
+//
+// Deprecated: ReadConfig ignores config files in parent directories, the
+// configs a file extends, and overrides, so it can disagree with the yam
+// command about how a file is formatted. Use yam.ConfigLoader instead.
 func ReadConfig() (*EncodeOptions, error) {
 	f, err := os.Open(util.ConfigFileName)
 	if err != nil {
@@ -110,16 +125,46 @@ func ReadConfig() (*EncodeOptions, error) {
 	return config, nil
 }
 
+// configFile has every setting of a yam config file, so that unknown settings
+// can be reported. Only the encoder's settings are used.
+type configFile struct {
+	EncodeOptions `yaml:",inline"`
+
+	Root         bool      `yaml:"root"`
+	Extends      []string  `yaml:"extends"`
+	FinalNewline *bool     `yaml:"final-newline"`
+	TrimLines    *bool     `yaml:"trim-lines"`
+	Recursive    *bool     `yaml:"recursive"`
+	Include      []string  `yaml:"include"`
+	Exclude      []string  `yaml:"exclude"`
+	Overrides    yaml.Node `yaml:"overrides"`
+}
+
 // ReadConfigFrom loads a yam encoder config from the given io.Reader. It
-// returns an error if it wasn't able to unmarshal the data.
+// returns an error if it wasn't able to unmarshal the data, if the data has
+// settings that yam config files don't have, or if any of the config's path
+// expressions are invalid. The settings that don't affect the encoder are
+// otherwise ignored; use yam.ReadFormatConfig to check a whole config file.
 func ReadConfigFrom(r io.Reader) (*EncodeOptions, error) {
-	options := EncodeOptions{}
+	var cfg configFile
 
-	err := yaml.NewDecoder(r).Decode(&options)
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	err := dec.Decode(&cfg)
 	if err != nil {
 		return nil, fmt.Errorf("parsing yam config: %w", err)
 	}
 
+	options := cfg.EncodeOptions
+	if options.Indent < 0 {
+		return nil, errors.New("invalid yam config: indent can't be negative")
+	}
+
+	// Check the path expressions now, rather than when the options are used.
+	if _, err := NewEncoder(io.Discard).UseOptions(options); err != nil {
+		return nil, fmt.Errorf("invalid yam config: %w", err)
+	}
+
 	return &options, nil
 }
 
@@ -220,7 +265,9 @@ func (enc Encoder) UseOptions(options EncodeOptions) (Encoder, error) {
 }
 
 // Encode writes out the formatted YAML from the given value to the encoder's
-// io.Writer.
+// io.Writer. When Encode is called more than once, each document after the
+// first is preceded by a document start marker ("---"), so that the output is
+// a valid multi-document YAML stream.
 func (enc Encoder) Encode(in any) error {
 	var node *yaml.Node
 	switch n := in.(type) {
@@ -243,6 +290,13 @@ func (enc Encoder) Encode(in any) error {
 		return err
 	}
 
+	if enc.documentCount != nil {
+		if *enc.documentCount > 0 {
+			b = bytes.Join([][]byte{documentStartMarker, newline, b}, nil)
+		}
+		*enc.documentCount++
+	}
+
 	_, err = enc.w.Write(b)
 	if err != nil {
 		return err
@@ -419,7 +473,7 @@ func (enc Encoder) marshalSequence(node *yaml.Node, nodePath path.Path) ([]byte,
 			item.HeadComment = ""
 		}
 
-		itemBytes, err := enc.marshal(item, nodePath.AppendSeqPart(i))
+		itemBytes, err := enc.marshal(item, nodePath.AppendSeqItemPart(node, i))
 		if err != nil {
 			return nil, err
 		}
@@ -497,40 +551,19 @@ func (enc Encoder) applyIndentExceptFirstLine(content []byte) []byte {
 }
 
 func (enc Encoder) matchesAnyGapPath(testSubject path.Path) bool {
-	for _, gp := range enc.gapPaths {
-		if gp.Matches(testSubject) {
-			return true
-		}
-	}
-
-	return false
+	return path.MatchesAny(enc.gapPaths, testSubject)
 }
 
 func (enc Encoder) matchesAnySortPath(testSubject path.Path) bool {
-	for _, sp := range enc.sortPaths {
-		if sp.Matches(testSubject) {
-			return true
-		}
-	}
-	return false
+	return path.MatchesAny(enc.sortPaths, testSubject)
 }
 
 func (enc Encoder) matchesAnyQuotePath(testSubject path.Path) bool {
-	for _, sp := range enc.quotePaths {
-		if sp.Matches(testSubject) {
-			return true
-		}
-	}
-	return false
+	return path.MatchesAny(enc.quotePaths, testSubject)
 }
 
 func (enc Encoder) matchesAnyDedupPath(testSubject path.Path) bool {
-	for _, dp := range enc.dedupPaths {
-		if dp.Matches(testSubject) {
-			return true
-		}
-	}
-	return false
+	return path.MatchesAny(enc.dedupPaths, testSubject)
 }
 
 func (enc Encoder) handleMultilineStringIndentation(content []byte) []byte {
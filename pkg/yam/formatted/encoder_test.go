@@ -29,6 +29,43 @@ func TestEncoder_AutomaticConfig(t *testing.T) {
 	})
 }
 
+func TestReadConfigFrom(t *testing.T) {
+	t.Run("whole config file", func(t *testing.T) {
+		config := `root: true
+extends: [base.yaml]
+indent: 4
+gap: !reset [.]
+sort: [.a]
+final-newline: false
+trim-lines: true
+recursive: true
+include: ['**/*.yaml']
+exclude: ['testdata/**']
+overrides:
+  - files: ['*.k8s.yaml']
+    indent: 2
+`
+		options, err := ReadConfigFrom(strings.NewReader(config))
+		require.NoError(t, err)
+		assert.Equal(t, &EncodeOptions{Indent: 4, GapExpressions: []string{"."}, SortExpressions: []string{".a"}}, options)
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		_, err := ReadConfigFrom(strings.NewReader("indent: 2\ngaps:\n  - .\n"))
+		assert.ErrorContains(t, err, "field gaps not found")
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := ReadConfigFrom(strings.NewReader("gap: ['.a[']\n"))
+		assert.ErrorContains(t, err, "invalid yam config")
+	})
+
+	t.Run("negative indent", func(t *testing.T) {
+		_, err := ReadConfigFrom(strings.NewReader("indent: -1\n"))
+		assert.ErrorContains(t, err, "invalid yam config")
+	})
+}
+
 func TestSortingSequence(t *testing.T) {
 	tests := []struct {
 		sortExpression string
@@ -38,6 +75,8 @@ func TestSortingSequence(t *testing.T) {
 		{sortExpression: ".sorted", nodePath: ".sorted", want: sorted},
 		{sortExpression: ".notsorted", nodePath: ".sorted", want: unsorted},
 		{sortExpression: ".sorted", nodePath: ".notsorted", want: unsorted},
+		{sortExpression: "..sorted", nodePath: ".a[0].b.sorted", want: sorted},
+		{sortExpression: "..sorted", nodePath: ".a[0].b.notsorted", want: unsorted},
 	}
 	for _, tc := range tests {
 		// Create an unsorted sequence.
@@ -145,6 +184,180 @@ func TestEncoder_Encode(t *testing.T) {
 	})
 }
 
+func TestEncoder_filters(t *testing.T) {
+	input := `subpackages:
+  - name: foo-dev
+    dependencies:
+      runtime:
+        - zlib
+        - bash
+  - name: foo-doc
+    dependencies:
+      runtime:
+        - zlib
+        - bash
+pipeline:
+  - uses: fetch
+    with:
+      uri: https://example.com
+      expected-sha256: abc
+  - runs: make
+`
+
+	expected := `subpackages:
+  - name: foo-dev
+    dependencies:
+      runtime:
+        - bash
+        - zlib
+  - name: foo-doc
+    dependencies:
+      runtime:
+        - zlib
+        - bash
+pipeline:
+  - uses: fetch
+
+    with:
+      uri: https://example.com
+      expected-sha256: abc
+  - runs: make
+`
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(input), &node))
+
+	var out bytes.Buffer
+	enc, err := NewEncoder(&out).UseOptions(EncodeOptions{
+		Indent:          2,
+		GapExpressions:  []string{".pipeline[uses=fetch]"},
+		SortExpressions: []string{`.subpackages[] | select(.name == "foo-dev") | .dependencies.runtime`},
+	})
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(&node))
+
+	checkDiff(t, expected, out.String())
+}
+
+func TestEncoder_indicesAndExclusions(t *testing.T) {
+	input := `args:
+  - run
+  - a
+  - b
+env:
+  HOME: /root
+  USER: root
+pipeline:
+  - uses: fetch
+    with: {}
+  - runs: make
+    name: build
+`
+
+	expected := `args:
+  - run
+  - "a"
+  - "b"
+env:
+  HOME: /root
+  USER: "root"
+pipeline:
+  - uses: fetch
+    with: {}
+  - runs: make
+
+    name: build
+`
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(input), &node))
+
+	var out bytes.Buffer
+	enc, err := NewEncoder(&out).UseOptions(EncodeOptions{
+		Indent:           2,
+		GapExpressions:   []string{".pipeline[-1]"},
+		QuoteExpressions: []string{".args[1:]", ".env.*", "!.env.HOME"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(&node))
+
+	checkDiff(t, expected, out.String())
+}
+
+func TestEncoder_EncodeMultipleDocuments(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+
+	require.NoError(t, enc.Encode(map[string]string{"a": "1"}))
+	require.NoError(t, enc.Encode(map[string]string{"b": "2"}))
+
+	checkDiff(t, "a: \"1\"\n---\nb: \"2\"\n", out.String())
+}
+
+func TestEncoder_EncodeStream(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "single document",
+			input:    "a:   1\nb: 2\n",
+			expected: "a: 1\nb: 2\n",
+		},
+		{
+			name:     "implicit first document",
+			input:    "a: 1\n---\nb: 2\n",
+			expected: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:     "end markers",
+			input:    "---\na: 1\n...\n---\nb: 2\n...\n",
+			expected: "---\na: 1\n...\n---\nb: 2\n...\n",
+		},
+		{
+			name:     "comments preceding start markers",
+			input:    "# first\n\n---\na: 1\n# second\n---\nb: 2\n",
+			expected: "# first\n\n---\na: 1\n# second\n---\nb: 2\n",
+		},
+		{
+			name:     "empty line before comments preceding start markers",
+			input:    "a: 1\n\n\n# second\n---\nb: 2\n...\n\n# third\n---\nc: 3\n",
+			expected: "a: 1\n\n# second\n---\nb: 2\n...\n\n# third\n---\nc: 3\n",
+		},
+		{
+			name:     "empty line kept by a block scalar before comments",
+			input:    "a: |+\n  text\n\n# second\n---\nb: 2\n",
+			expected: "a: |+\n  text\n\n# second\n---\nb: 2\n",
+		},
+		{
+			name:     "comment after start marker",
+			input:    "--- # hello\na: 1\n",
+			expected: "--- # hello\na: 1\n",
+		},
+		{
+			name:     "empty documents",
+			input:    "---\n---\na: 1\n",
+			expected: "---\n---\na: 1\n",
+		},
+		{
+			name:     "document after end marker without start marker",
+			input:    "a: 1\n...\nb: 2\n",
+			expected: "a: 1\n...\nb: 2\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := NewEncoder(&out).EncodeStream(strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			checkDiff(t, tt.expected, out.String())
+		})
+	}
+}
+
 func TestDedupSequence(t *testing.T) {
 	tests := []struct {
 		name            string
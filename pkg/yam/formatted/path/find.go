@@ -0,0 +1,80 @@
+package path
+
+import "gopkg.in/yaml.v3"
+
+// Match is a node that matches a path expression.
+type Match struct {
+	// Path is the concrete path of the node, e.g. ".subpackages[0].name" for the
+	// expression ".subpackages[].name".
+	Path Path
+
+	// Node is the node that matches.
+	Node *yaml.Node
+
+	// Parent is the mapping or sequence node that Node is a value or item of, or
+	// nil if Node is the root node.
+	Parent *yaml.Node
+
+	// Key is the key node for Node if Parent is a mapping, or nil otherwise.
+	Key *yaml.Node
+}
+
+// Find returns the nodes in the YAML tree at root that p matches, in document
+// order. root can be a document node, in which case its content is used as the
+// root node. Aliases aren't followed, so the nodes they refer to are only
+// matched where they're defined.
+//
+// A negated path, e.g. "!.pipeline[0]", doesn't find any nodes, since it only
+// excludes paths from what other paths match, as in MatchesAny.
+//
+// The nodes can be modified through the matches, e.g. to change their values,
+// and the matches stay valid as long as the nodes' parents aren't changed.
+func (p Path) Find(root *yaml.Node) []Match {
+	if root == nil {
+		return nil
+	}
+
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil
+		}
+		root = root.Content[0]
+	}
+
+	var matches []Match
+	p.find(Match{Path: Root(), Node: root}, &matches)
+
+	return matches
+}
+
+// find adds m to matches if p matches its path, and then does the same for the
+// children of its node.
+func (p Path) find(m Match, matches *[]Match) {
+	if p.Matches(m.Path) {
+		*matches = append(*matches, m)
+	}
+
+	n := m.Node
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			p.find(Match{
+				Path:   m.Path.AppendMapPart(key.Value),
+				Node:   value,
+				Parent: n,
+				Key:    key,
+			}, matches)
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			p.find(Match{
+				Path:   m.Path.AppendSeqItemPart(n, i),
+				Node:   item,
+				Parent: n,
+			}, matches)
+		}
+	}
+}
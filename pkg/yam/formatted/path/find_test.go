@@ -0,0 +1,141 @@
+package path
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const melangeYAML = `package:
+  name: foo
+pipeline:
+  - uses: fetch
+    with:
+      uri: https://example.com/foo.tar.gz
+  - runs: make
+subpackages:
+  - name: foo-dev
+    pipeline:
+      - uses: split/dev
+  - name: foo-doc
+base: &base
+  name: base
+copy: *base
+`
+
+func TestPath_Find(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(melangeYAML), &doc))
+
+	cases := []struct {
+		expression string
+		expected   []string
+		values     []string
+	}{
+		{
+			expression: ".subpackages[].name",
+			expected:   []string{".subpackages[0].name", ".subpackages[1].name"},
+			values:     []string{"foo-dev", "foo-doc"},
+		},
+		{
+			expression: "..uses",
+			expected:   []string{".pipeline[0].uses", ".subpackages[0].pipeline[0].uses"},
+			values:     []string{"fetch", "split/dev"},
+		},
+		{
+			expression: ".subpackages[name=foo-dev].pipeline[-1].uses",
+			expected:   []string{".subpackages[0].pipeline[0].uses"},
+			values:     []string{"split/dev"},
+		},
+		{
+			expression: "..name",
+			expected:   []string{".package.name", ".subpackages[0].name", ".subpackages[1].name", ".base.name"},
+			values:     []string{"foo", "foo-dev", "foo-doc", "base"},
+		},
+		{
+			expression: ".missing",
+		},
+		{
+			expression: "!.package",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.expression, func(t *testing.T) {
+			p, err := Parse(tt.expression)
+			require.NoError(t, err)
+
+			var paths, values []string
+			for _, m := range p.Find(&doc) {
+				paths = append(paths, m.Path.String())
+				values = append(values, m.Node.Value)
+			}
+
+			assert.Equal(t, tt.expected, paths)
+			assert.Equal(t, tt.values, values)
+		})
+	}
+}
+
+func TestPath_Find_parents(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(melangeYAML), &doc))
+	root := doc.Content[0]
+
+	t.Run("root", func(t *testing.T) {
+		matches := Root().Find(&doc)
+		require.Len(t, matches, 1)
+
+		assert.Same(t, root, matches[0].Node)
+		assert.Nil(t, matches[0].Parent)
+		assert.Nil(t, matches[0].Key)
+		assert.Equal(t, ".", matches[0].Path.String())
+	})
+
+	t.Run("mapping value", func(t *testing.T) {
+		p, err := Parse(".package.name")
+		require.NoError(t, err)
+
+		matches := p.Find(root)
+		require.Len(t, matches, 1)
+
+		assert.Same(t, root.Content[1], matches[0].Parent)
+		assert.Equal(t, "name", matches[0].Key.Value)
+	})
+
+	t.Run("sequence item", func(t *testing.T) {
+		p, err := Parse(".subpackages[1]")
+		require.NoError(t, err)
+
+		matches := p.Find(root)
+		require.Len(t, matches, 1)
+
+		assert.Same(t, root.Content[5], matches[0].Parent)
+		assert.Same(t, root.Content[5].Content[1], matches[0].Node)
+		assert.Nil(t, matches[0].Key)
+	})
+
+	t.Run("modifying nodes", func(t *testing.T) {
+		var doc yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(melangeYAML), &doc))
+
+		p, err := Parse(".subpackages[].name")
+		require.NoError(t, err)
+
+		for _, m := range p.Find(&doc) {
+			m.Node.Value = "renamed-" + m.Node.Value
+		}
+
+		out, err := yaml.Marshal(&doc)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "name: renamed-foo-dev")
+		assert.Contains(t, string(out), "name: renamed-foo-doc")
+	})
+
+	t.Run("empty document", func(t *testing.T) {
+		assert.Empty(t, Root().Find(&yaml.Node{Kind: yaml.DocumentNode}))
+		assert.Empty(t, Root().Find(nil))
+	})
+}
@@ -1,8 +1,17 @@
 package path
 
-import "strconv"
+import (
+	"regexp"
+	"strconv"
+	"strings"
 
-const root = "root"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	root       = "root"
+	descendant = "descendant"
+)
 
 type PartKind int
 
@@ -10,11 +19,7 @@ const (
 	RootKind PartKind = iota
 	MapKind
 	SeqKind
-)
-
-const (
-	anyKey   = "//any-key//"
-	anyIndex = -1
+	DescendantKind
 )
 
 type Part interface {
@@ -24,9 +29,16 @@ type Part interface {
 
 type mapPart struct {
 	key string
+
+	// wildcard is set for the "*" in a pattern, which matches any key.
+	wildcard bool
 }
 
 func (p mapPart) id() string {
+	if p.wildcard {
+		return anyKeyExpression
+	}
+
 	return p.key
 }
 
@@ -34,11 +46,104 @@ func (p mapPart) Kind() PartKind {
 	return MapKind
 }
 
+// globPart matches the map keys that match a glob pattern, in which "*"
+// matches any sequence of characters and "?" matches any single character,
+// e.g. "FOO_*".
+type globPart struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newGlobPart(pattern string) globPart {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	return globPart{
+		pattern: pattern,
+		re:      regexp.MustCompile(expr.String()),
+	}
+}
+
+func (p globPart) id() string {
+	return p.pattern
+}
+
+func (p globPart) Kind() PartKind {
+	return MapKind
+}
+
+// regexPart matches the map keys that match a regular expression, e.g.
+// "/^build-.*/". Like in Go's regexp package, the expression isn't anchored.
+type regexPart struct {
+	re *regexp.Regexp
+}
+
+func (p regexPart) id() string {
+	return p.re.String()
+}
+
+func (p regexPart) Kind() PartKind {
+	return MapKind
+}
+
+// keySetPart matches any of a set of map keys, e.g.
+// "{dependencies,devDependencies}".
+type keySetPart struct {
+	keys []string
+}
+
+func (p keySetPart) id() string {
+	return strings.Join(p.keys, ",")
+}
+
+func (p keySetPart) Kind() PartKind {
+	return MapKind
+}
+
 type seqPart struct {
+	// index is the index of the item. In a pattern, a negative index counts from
+	// the end of the sequence.
 	index int
+
+	// wildcard is set for the "[]" in a pattern, which matches any item.
+	wildcard bool
+
+	// length is the length of the sequence, and item is the sequence item the
+	// part refers to, if they're known, i.e. if length isn't 0. They're used to
+	// check negative indices, slices and filters.
+	length int
+	item   *yaml.Node
+}
+
+// resolve returns the index, which can be negative to count from the end, as
+// an index in the sequence of the part. It returns false if that isn't known.
+func (p seqPart) resolve(index int) (int, bool) {
+	if index >= 0 {
+		return index, true
+	}
+	if p.length == 0 {
+		return 0, false
+	}
+
+	return p.length + index, true
 }
 
 func (p seqPart) id() string {
+	if p.wildcard {
+		return anySeqItemExpression
+	}
+
 	return strconv.Itoa(p.index)
 }
 
@@ -55,3 +160,105 @@ func (p rootPart) id() string {
 func (p rootPart) Kind() PartKind {
 	return RootKind
 }
+
+// descendantPart matches zero or more parts of any kind, for the recursive
+// descent operator "..".
+type descendantPart struct{}
+
+func (p descendantPart) id() string {
+	return descendant
+}
+
+func (p descendantPart) Kind() PartKind {
+	return DescendantKind
+}
+
+// filterPart matches the sequence items that are mappings in which key is set
+// to value, e.g. "[name=foo-dev]".
+type filterPart struct {
+	key   string
+	value string
+}
+
+func (p filterPart) id() string {
+	return p.key + "=" + p.value
+}
+
+func (p filterPart) Kind() PartKind {
+	return SeqKind
+}
+
+// matches reports whether the given sequence item matches the filter.
+func (p filterPart) matches(item *yaml.Node) bool {
+	if item == nil {
+		return false
+	}
+	if item.Kind == yaml.AliasNode {
+		item = item.Alias
+	}
+	if item.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		key, value := item.Content[i], item.Content[i+1]
+		if key.Kind != yaml.ScalarNode || key.Value != p.key {
+			continue
+		}
+
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+
+		return value.Kind == yaml.ScalarNode && value.Value == p.value
+	}
+
+	return false
+}
+
+// slicePart matches the sequence items from the start index up to, but not
+// including, the end index, e.g. "[1:3]". Either index can be negative to count
+// from the end of the sequence, and the end index can be left out, to include
+// every item after the start.
+type slicePart struct {
+	start int
+	end   *int
+}
+
+func (p slicePart) id() string {
+	return partExpression(p)
+}
+
+func (p slicePart) Kind() PartKind {
+	return SeqKind
+}
+
+// matches reports whether the given sequence item is in the slice.
+func (p slicePart) matches(item seqPart) bool {
+	start, ok := item.resolve(p.start)
+	if !ok || item.index < start {
+		return false
+	}
+
+	if p.end == nil {
+		return true
+	}
+
+	end, ok := item.resolve(*p.end)
+	return ok && item.index < end
+}
+
+// exceptPart matches what the base part matches, unless the except part
+// matches it too, e.g. "*!metadata".
+type exceptPart struct {
+	base   Part
+	except Part
+}
+
+func (p exceptPart) id() string {
+	return partExpression(p)
+}
+
+func (p exceptPart) Kind() PartKind {
+	return p.base.Kind()
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Note: While YAML allows non-scalar mapping keys, we'll constrain this
@@ -13,6 +16,10 @@ import (
 
 type Path struct {
 	parts []Part
+
+	// negated is set for expressions that start with "!", which exclude the
+	// paths that the rest of the expression matches; see MatchesAny.
+	negated bool
 }
 
 func Root() Path {
@@ -22,102 +29,437 @@ func Root() Path {
 }
 
 const (
-	rootExpression   = "."
-	anyKeyExpression = "*"
+	rootExpression       = "."
+	anyKeyExpression     = "*"
+	anySeqItemExpression = "[]"
+	descendantExpression = ".."
+	negationExpression   = "!"
 )
 
 var ErrExpressionNotSupported = errors.New("expression not supported")
 
 var (
-	regexMapKey   = regexp.MustCompile(`^(\.([0-9a-zA-Z_-]+|\*))([\[.].*)?`)
-	regexSeqIndex = regexp.MustCompile(`^\[(\d*)]`)
-	regexRootSeq  = regexp.MustCompile(`^\.\[(\d*)]`)
+	regexMapKey   = regexp.MustCompile(`^(\.([0-9a-zA-Z_*?-]+))([\[.].*)?`)
+	regexSeqIndex = regexp.MustCompile(`^\.?\[(-?\d*)]`)
+	regexSeqSlice = regexp.MustCompile(`^\.?\[(-?\d*):(-?\d*)]`)
+
+	// regexPlainKey matches the map keys that can be used in expressions without
+	// quotes, and regexPlainKeyPrefix matches one at the start of a string.
+	// regexPlainValue matches the values that can be used in filters without
+	// quotes.
+	regexPlainKey       = regexp.MustCompile(`^[0-9a-zA-Z_-]+$`)
+	regexPlainKeyPrefix = regexp.MustCompile(`^[0-9a-zA-Z_-]+`)
+	regexPlainValue     = regexp.MustCompile(`^[0-9a-zA-Z_./+-]+$`)
+
+	// regexFilter matches a filter on sequence items, e.g. "[name=foo-dev]", and
+	// regexSelect matches a yq-style select, e.g. `| select(.name == "foo-dev")`.
+	// Keys and values can be quoted.
+	regexFilter = regexp.MustCompile(`^\.?\[\s*([0-9a-zA-Z_-]+|"(?:[^"\\]|\\.)*")\s*=\s*([0-9a-zA-Z_./+-]+|"(?:[^"\\]|\\.)*")\s*]`)
+	regexSelect = regexp.MustCompile(`^\s*\|\s*select\(\s*\.([0-9a-zA-Z_-]+|"(?:[^"\\]|\\.)*")\s*==\s*([0-9a-zA-Z_./+-]+|"(?:[^"\\]|\\.)*")\s*\)`)
+
+	// regexPipe matches a pipe into the rest of the expression, e.g. the " | " in
+	// `.subpackages[] | select(.name == "foo-dev") | .dependencies`.
+	regexPipe = regexp.MustCompile(`^\s*\|\s*`)
 )
 
+// Parse parses a yq-style path expression, e.g. ".some-key[].other-key" or
+// ".types.*.inputs[0]". Map keys that have characters other than letters,
+// digits, "_" and "-" are written as double-quoted strings, either after a dot
+// (.metadata.labels."app.kubernetes.io/name") or in brackets
+// (.metadata.labels["app.kubernetes.io/name"]), using Go's escape sequences.
+//
+// A ".." in place of a "." is the recursive descent operator, which matches the
+// following part at any depth, e.g. "..deps" or ".pipeline..with[0]".
+//
+// Sequence items can be filtered by the value of one of their keys, with
+// "[key=value]" in place of "[]", e.g. ".subpackages[name=foo-dev].dependencies",
+// or with a yq-style select after "[]", e.g.
+// `.subpackages[] | select(.name == "foo-dev") | .dependencies`. Only the items
+// that are mappings in which the key is set to the value match.
+//
+// Besides "*", which matches any key, keys can be matched by a glob pattern,
+// e.g. ".env.FOO_*", where "*" matches any characters and "?" matches any one
+// character; by a regular expression between slashes, e.g.
+// ".jobs./^build-.*/.steps"; or by a set of keys, e.g.
+// ".{dependencies,devDependencies}".
+//
+// Sequence indices can be negative, to count from the end of the sequence, e.g.
+// "[-1]" for the last item, and a range of items can be matched with a slice,
+// e.g. "[1:]" or "[:-1]", which includes the start index and excludes the end
+// index, like Python's slices.
+//
+// A part followed by "!" and another part matches what the first part matches
+// unless the second part matches it too, e.g. ".*!metadata" or ".pipeline[]![0]".
+// An expression that starts with "!", e.g. "!.pipeline[0]", excludes the paths
+// that the rest of the expression matches from the ones that other expressions
+// match. It doesn't match any path on its own; see MatchesAny.
 func Parse(expression string) (Path, error) {
+	negated := strings.HasPrefix(expression, negationExpression)
+	expression = strings.TrimPrefix(expression, negationExpression)
+
 	if expression == rootExpression {
-		return Root(), nil
+		result := Root()
+		result.negated = negated
+		return result, nil
+	}
+	if negated && expression == "" {
+		return Path{}, ErrExpressionNotSupported
 	}
 
 	result := Root() // i.e., so far
 
 	remaining := expression
-	for {
-		if remaining == "" {
-			return result, nil
-		}
-
-		//  check for the expression to specify a sequence index right off the bat, e.g. ".[42]"
-		submatches := regexRootSeq.FindStringSubmatch(expression)
-		if len(submatches) >= 2 {
-			indexString := submatches[1]
-			if indexString == "" {
-				result = result.AppendSeqPart(anyIndex)
-				remaining = strings.TrimPrefix(remaining, submatches[0])
-				continue
+	for remaining != "" {
+		// check for an exclusion from the last part, e.g. the "!metadata" in
+		// ".*!metadata", which is parsed as if it were a part of its own
+		if strings.HasPrefix(remaining, negationExpression) {
+			base := result.Last()
+			if base.Kind() != MapKind && base.Kind() != SeqKind {
+				return Path{}, ErrExpressionNotSupported
 			}
 
-			index, err := strconv.Atoi(indexString)
+			excluded, rest, err := parseStep(Root(), "."+remaining[1:])
 			if err != nil {
+				return Path{}, err
+			}
+			if excluded.Len() != 2 || excluded.Last().Kind() != base.Kind() {
 				return Path{}, ErrExpressionNotSupported
 			}
-			result = result.AppendSeqPart(index)
-			remaining = strings.TrimPrefix(remaining, submatches[0])
+
+			result.parts[len(result.parts)-1] = exceptPart{base: base, except: excluded.Last()}
+			remaining = rest
 			continue
 		}
 
-		// check for map key, e.g. ".some-key"
-		submatches = regexMapKey.FindStringSubmatch(remaining)
-		if len(submatches) >= 3 {
-			key := submatches[2]
-			if key == anyKeyExpression {
-				result = result.AppendMapPart(anyKey)
-				remaining = strings.TrimPrefix(remaining, submatches[1])
-				continue
-			}
+		var err error
+		result, remaining, err = parseStep(result, remaining)
+		if err != nil {
+			return Path{}, err
+		}
+	}
 
-			result = result.AppendMapPart(key)
-			remaining = strings.TrimPrefix(remaining, submatches[1])
-			continue
+	result.negated = negated
+	return result, nil
+}
+
+// parseStep parses the next step of an expression at the start of remaining,
+// which is usually one part, and returns the path with it and what's left of
+// the expression.
+func parseStep(result Path, remaining string) (Path, string, error) {
+	// check for recursive descent, e.g. "..some-key"; the rest is parsed as if it
+	// had a single dot
+	if strings.HasPrefix(remaining, descendantExpression) {
+		if _, ok := result.Last().(descendantPart); ok {
+			return Path{}, "", ErrExpressionNotSupported
 		}
 
-		// check for seq index, e.g. "[7]"
-		submatches = regexSeqIndex.FindStringSubmatch(remaining)
-		if len(submatches) >= 2 {
-			indexString := submatches[1]
-			if indexString == "" {
-				result = result.AppendSeqPart(anyIndex)
-				remaining = strings.TrimPrefix(remaining, submatches[0])
-				continue
+		return result.appendPart(descendantPart{}), remaining[1:], nil
+	}
+
+	// check for a filter on sequence items, e.g. "[name=foo-dev]"
+	submatches := regexFilter.FindStringSubmatch(remaining)
+	if len(submatches) >= 3 {
+		filter, err := newFilterPart(submatches[1], submatches[2])
+		if err != nil {
+			return Path{}, "", err
+		}
+		return result.appendPart(filter), strings.TrimPrefix(remaining, submatches[0]), nil
+	}
+
+	// check for a select on the items matched by "[]", e.g.
+	// `[] | select(.name == "foo-dev")`
+	submatches = regexSelect.FindStringSubmatch(remaining)
+	if len(submatches) >= 3 {
+		if last, ok := result.Last().(seqPart); !ok || !last.wildcard {
+			return Path{}, "", ErrExpressionNotSupported
+		}
+		filter, err := newFilterPart(submatches[1], submatches[2])
+		if err != nil {
+			return Path{}, "", err
+		}
+		result.parts[len(result.parts)-1] = filter
+		return result, strings.TrimPrefix(remaining, submatches[0]), nil
+	}
+
+	// check for a pipe into the rest of the expression, e.g. " | .dependencies"
+	if pipe := regexPipe.FindString(remaining); pipe != "" {
+		remaining = strings.TrimPrefix(remaining, pipe)
+		if remaining == "" {
+			return Path{}, "", ErrExpressionNotSupported
+		}
+		return result, remaining, nil
+	}
+
+	// check for a regular expression for map keys, e.g. "./^build-.*/"
+	part, rest, ok, err := parseRegexKey(remaining)
+	if err != nil {
+		return Path{}, "", err
+	}
+	if ok {
+		return result.appendPart(part), rest, nil
+	}
+
+	// check for a set of map keys, e.g. ".{dependencies,devDependencies}"
+	set, rest, ok, err := parseKeySet(remaining)
+	if err != nil {
+		return Path{}, "", err
+	}
+	if ok {
+		return result.appendPart(set), rest, nil
+	}
+
+	// check for a quoted map key, e.g. `."some.key"` or `["some.key"]`
+	key, rest, ok, err := parseQuotedKey(remaining)
+	if err != nil {
+		return Path{}, "", err
+	}
+	if ok {
+		return result.AppendMapPart(key), rest, nil
+	}
+
+	// check for a slice of a sequence, e.g. "[1:]" or "[:-1]"
+	submatches = regexSeqSlice.FindStringSubmatch(remaining)
+	if len(submatches) >= 3 {
+		slice, err := newSlicePart(submatches[1], submatches[2])
+		if err != nil {
+			return Path{}, "", err
+		}
+		return result.appendPart(slice), strings.TrimPrefix(remaining, submatches[0]), nil
+	}
+
+	// check for seq index, e.g. "[7]", "[-1]" or ".[42]" right off the bat
+	submatches = regexSeqIndex.FindStringSubmatch(remaining)
+	if len(submatches) >= 2 {
+		indexString := submatches[1]
+		if indexString == "" {
+			return result.appendPart(seqPart{wildcard: true}), strings.TrimPrefix(remaining, submatches[0]), nil
+		}
+
+		index, err := strconv.Atoi(indexString)
+		if err != nil {
+			return Path{}, "", ErrExpressionNotSupported
+		}
+		return result.AppendSeqPart(index), strings.TrimPrefix(remaining, submatches[0]), nil
+	}
+
+	// check for map key, e.g. ".some-key"
+	submatches = regexMapKey.FindStringSubmatch(remaining)
+	if len(submatches) >= 3 {
+		key := submatches[2]
+		remaining = strings.TrimPrefix(remaining, submatches[1])
+
+		switch {
+		case key == anyKeyExpression:
+			return result.appendPart(mapPart{wildcard: true}), remaining, nil
+		case strings.ContainsAny(key, "*?"):
+			return result.appendPart(newGlobPart(key)), remaining, nil
+		default:
+			return result.AppendMapPart(key), remaining, nil
+		}
+	}
+
+	// nothing else it could be
+	return Path{}, "", ErrExpressionNotSupported
+}
+
+// parseQuotedKey parses a quoted map key at the start of s, in any of the
+// `."key"`, `.["key"]` or `["key"]` forms, and returns the key and the rest of
+// s. It returns false if s doesn't start with a quoted key.
+func parseQuotedKey(s string) (string, string, bool, error) {
+	var bracketed bool
+	switch {
+	case strings.HasPrefix(s, `."`):
+		s = s[1:]
+	case strings.HasPrefix(s, `.["`):
+		s = s[2:]
+		bracketed = true
+	case strings.HasPrefix(s, `["`):
+		s = s[1:]
+		bracketed = true
+	default:
+		return "", "", false, nil
+	}
+
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", "", false, ErrExpressionNotSupported
+	}
+
+	key, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", "", false, ErrExpressionNotSupported
+	}
+
+	rest := s[len(quoted):]
+	if bracketed {
+		if !strings.HasPrefix(rest, "]") {
+			return "", "", false, ErrExpressionNotSupported
+		}
+		rest = rest[1:]
+	}
+
+	return key, rest, true, nil
+}
+
+// parseRegexKey parses a regular expression for map keys between slashes at
+// the start of s, e.g. "./^build-.*/", and returns it and the rest of s. A slash
+// in the expression is escaped with a backslash. It returns false if s doesn't
+// start with a regular expression.
+func parseRegexKey(s string) (regexPart, string, bool, error) {
+	if !strings.HasPrefix(s, "./") {
+		return regexPart{}, "", false, nil
+	}
+	s = s[2:]
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			// An escaped slash is also a valid regular expression for a slash, so
+			// the expression doesn't need to be unescaped.
+			re, err := regexp.Compile(s[:i])
+			if err != nil {
+				return regexPart{}, "", false, fmt.Errorf("%w: %v", ErrExpressionNotSupported, err)
 			}
 
-			index, err := strconv.Atoi(indexString)
+			return regexPart{re: re}, s[i+1:], true, nil
+		}
+	}
+
+	return regexPart{}, "", false, ErrExpressionNotSupported
+}
+
+// parseKeySet parses a set of map keys at the start of s, e.g.
+// ".{dependencies,devDependencies}", and returns it and the rest of s. Keys can
+// be quoted. It returns false if s doesn't start with a set of keys.
+func parseKeySet(s string) (keySetPart, string, bool, error) {
+	if !strings.HasPrefix(s, ".{") {
+		return keySetPart{}, "", false, nil
+	}
+	s = s[2:]
+
+	var keys []string
+	for {
+		s = strings.TrimLeft(s, " ")
+
+		var key string
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
 			if err != nil {
-				return Path{}, ErrExpressionNotSupported
+				return keySetPart{}, "", false, ErrExpressionNotSupported
 			}
-			result = result.AppendSeqPart(index)
-			remaining = strings.TrimPrefix(remaining, submatches[0])
-			continue
+			key, err = strconv.Unquote(quoted)
+			if err != nil {
+				return keySetPart{}, "", false, ErrExpressionNotSupported
+			}
+			s = s[len(quoted):]
+		} else {
+			key = regexPlainKeyPrefix.FindString(s)
+			if key == "" {
+				return keySetPart{}, "", false, ErrExpressionNotSupported
+			}
+			s = s[len(key):]
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimLeft(s, " ")
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case strings.HasPrefix(s, "}"):
+			return keySetPart{keys: keys}, s[1:], true, nil
+		default:
+			return keySetPart{}, "", false, ErrExpressionNotSupported
 		}
+	}
+}
 
-		// nothing else it could be
-		return Path{}, ErrExpressionNotSupported
+// newSlicePart returns the slice for the start and end indices matched by
+// regexSeqSlice, either of which can be empty.
+func newSlicePart(start, end string) (slicePart, error) {
+	var slice slicePart
+
+	if start != "" {
+		i, err := strconv.Atoi(start)
+		if err != nil {
+			return slicePart{}, ErrExpressionNotSupported
+		}
+		slice.start = i
 	}
+
+	if end != "" {
+		i, err := strconv.Atoi(end)
+		if err != nil {
+			return slicePart{}, ErrExpressionNotSupported
+		}
+		slice.end = &i
+	}
+
+	return slice, nil
 }
 
-func (p Path) AppendMapPart(key string) Path {
-	return Path{
-		parts: append(p.parts, mapPart{
-			key: key,
-		}),
+// newFilterPart returns the filter for the key and value matched by regexFilter
+// or regexSelect, either of which can be quoted.
+func newFilterPart(key, value string) (filterPart, error) {
+	key, err := unquoteIfQuoted(key)
+	if err != nil {
+		return filterPart{}, err
 	}
+
+	value, err = unquoteIfQuoted(value)
+	if err != nil {
+		return filterPart{}, err
+	}
+
+	return filterPart{key: key, value: value}, nil
+}
+
+func unquoteIfQuoted(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return "", ErrExpressionNotSupported
+	}
+
+	return unquoted, nil
+}
+
+func (p Path) AppendMapPart(key string) Path {
+	return p.appendPart(mapPart{
+		key: key,
+	})
 }
 
+// AppendSeqPart appends a part for the item at the given index of a sequence.
+// As in expressions, a negative index counts from the end, so -1 is the last
+// item. (It used to mean any item; use Parse("[]") for a path that matches
+// any item instead.)
 func (p Path) AppendSeqPart(index int) Path {
+	return p.appendPart(seqPart{
+		index: index,
+	})
+}
+
+// AppendSeqItemPart is like AppendSeqPart for the item of the sequence node seq
+// at the given index, but also records the item and the length of the
+// sequence, so that filters, negative indices and slices in patterns can be
+// checked against it.
+func (p Path) AppendSeqItemPart(seq *yaml.Node, index int) Path {
+	return p.appendPart(seqPart{
+		index:  index,
+		length: len(seq.Content),
+		item:   seq.Content[index],
+	})
+}
+
+func (p Path) appendPart(part Part) Path {
+	// Paths that share a parent mustn't share the parent's backing array.
 	return Path{
-		parts: append(p.parts, seqPart{
-			index: index,
-		}),
+		parts: append(slices.Clip(p.parts), part),
 	}
 }
 
@@ -133,36 +475,168 @@ func (p Path) Last() Part {
 func (p Path) String() string {
 	var result string
 
-	for _, part := range p.parts {
-		switch tp := part.(type) {
+	for i, part := range p.parts {
+		switch part.(type) {
 		case rootPart:
 			result = ""
-		case mapPart:
-			result += fmt.Sprintf(".%s", tp.key)
-		case seqPart:
-			result += fmt.Sprintf("[%d]", tp.index)
+		case descendantPart:
+			result += "."
+
+			// Sequence parts are written with a dot after a "..", e.g. "..[0]".
+			if i+1 < len(p.parts) && p.parts[i+1].Kind() == SeqKind {
+				result += "."
+			}
+		default:
+			result += partExpression(part)
 		}
 	}
 
 	if result == "" {
-		return rootExpression
+		result = rootExpression
+	}
+
+	if p.negated {
+		return negationExpression + result
 	}
 
 	return result
 }
 
-func (p Path) Matches(testSubject Path) bool {
-	if len(p.parts) != len(testSubject.parts) {
-		return false
+// partExpression returns the expression for a map or sequence part, e.g.
+// ".some-key" or "[0]".
+func partExpression(part Part) string {
+	switch tp := part.(type) {
+	case mapPart:
+		if tp.wildcard {
+			return "." + anyKeyExpression
+		}
+		return "." + quoteKeyIfNeeded(tp.key)
+	case seqPart:
+		if tp.wildcard {
+			return anySeqItemExpression
+		}
+		return fmt.Sprintf("[%d]", tp.index)
+	case slicePart:
+		var start, end string
+		if tp.start != 0 {
+			start = strconv.Itoa(tp.start)
+		}
+		if tp.end != nil {
+			end = strconv.Itoa(*tp.end)
+		}
+		return fmt.Sprintf("[%s:%s]", start, end)
+	case globPart:
+		return "." + tp.pattern
+	case regexPart:
+		return "./" + tp.re.String() + "/"
+	case keySetPart:
+		keys := make([]string, 0, len(tp.keys))
+		for _, key := range tp.keys {
+			keys = append(keys, quoteKeyIfNeeded(key))
+		}
+		return ".{" + strings.Join(keys, ",") + "}"
+	case filterPart:
+		key := quoteKeyIfNeeded(tp.key)
+		value := tp.value
+		if !regexPlainValue.MatchString(value) {
+			value = strconv.Quote(value)
+		}
+		return fmt.Sprintf("[%s=%s]", key, value)
+	case exceptPart:
+		return partExpression(tp.base) + negationExpression + strings.TrimPrefix(partExpression(tp.except), ".")
 	}
 
-	for i, patternPart := range p.parts {
-		if !partsMatch(patternPart, testSubject.parts[i]) {
+	return ""
+}
+
+// quoteKeyIfNeeded returns the map key as it's written in expressions, quoted
+// if it has characters other than letters, digits, "_" and "-".
+func quoteKeyIfNeeded(key string) string {
+	if regexPlainKey.MatchString(key) {
+		return key
+	}
+
+	return strconv.Quote(key)
+}
+
+// Matches reports whether the path p, used as a pattern, matches testSubject.
+// A negated pattern never matches, since it only excludes paths from what
+// other patterns match; use MatchesAny to combine it with them.
+//
+// The pattern is run as a small NFA whose states are the positions in its
+// parts: a state moves to the next one when its part matches the next part of
+// the test subject, and a descendantPart's state can also stay where it is, to
+// match any part, or move on without matching one.
+func (p Path) Matches(testSubject Path) bool {
+	return !p.negated && p.matches(testSubject)
+}
+
+func (p Path) matches(testSubject Path) bool {
+	states := make([]bool, len(p.parts)+1)
+	states[0] = true
+	p.skipDescendants(states)
+
+	for _, subjectPart := range testSubject.parts {
+		next := make([]bool, len(states))
+		active := false
+
+		for i, patternPart := range p.parts {
+			if !states[i] {
+				continue
+			}
+
+			switch {
+			case patternPart.Kind() == DescendantKind && subjectPart.Kind() != RootKind:
+				next[i] = true
+				active = true
+			case partsMatch(patternPart, subjectPart):
+				next[i+1] = true
+				active = true
+			}
+		}
+
+		if !active {
 			return false
 		}
+
+		p.skipDescendants(next)
+		states = next
 	}
 
-	return true
+	return states[len(p.parts)]
+}
+
+// MatchesAny reports whether testSubject matches any of the patterns that
+// aren't negated, and isn't excluded by any of the negated ones. For example,
+// the patterns ".pipeline[]" and "!.pipeline[0]" match every item of
+// .pipeline except the first.
+func MatchesAny(patterns []Path, testSubject Path) bool {
+	var matched bool
+
+	for _, p := range patterns {
+		if p.negated {
+			if p.matches(testSubject) {
+				return false
+			}
+			continue
+		}
+
+		if !matched && p.Matches(testSubject) {
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+// skipDescendants adds the states that can be reached from the given states by
+// matching zero parts with a descendantPart.
+func (p Path) skipDescendants(states []bool) {
+	for i, part := range p.parts {
+		if states[i] && part.Kind() == DescendantKind {
+			states[i+1] = true
+		}
+	}
 }
 
 func partsMatch(pattern, testSubject Part) bool {
@@ -176,11 +650,38 @@ func partsMatch(pattern, testSubject Part) bool {
 
 	case mapPart:
 		ts, _ := testSubject.(mapPart)
-		return tp.key == ts.key || tp.key == anyKey
+		return tp.wildcard || tp.key == ts.key
 
 	case seqPart:
 		ts, _ := testSubject.(seqPart)
-		return tp.index == ts.index || tp.index == anyIndex
+		if tp.wildcard {
+			return true
+		}
+		index, ok := ts.resolve(tp.index)
+		return ok && index == ts.index
+
+	case slicePart:
+		ts, _ := testSubject.(seqPart)
+		return tp.matches(ts)
+
+	case globPart:
+		ts, _ := testSubject.(mapPart)
+		return tp.re.MatchString(ts.key)
+
+	case regexPart:
+		ts, _ := testSubject.(mapPart)
+		return tp.re.MatchString(ts.key)
+
+	case keySetPart:
+		ts, _ := testSubject.(mapPart)
+		return slices.Contains(tp.keys, ts.key)
+
+	case filterPart:
+		ts, ok := testSubject.(seqPart)
+		return ok && tp.matches(ts.item)
+
+	case exceptPart:
+		return partsMatch(tp.base, testSubject) && !partsMatch(tp.except, testSubject)
 	}
 
 	return false
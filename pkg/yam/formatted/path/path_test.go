@@ -1,12 +1,25 @@
 package path
 
 import (
+	"math"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
+// pathCmpOptions are the options for comparing paths with cmp.
+var pathCmpOptions = cmp.Options{
+	cmp.AllowUnexported(Path{}, rootPart{}, mapPart{}, seqPart{}, descendantPart{}, filterPart{}, globPart{}, regexPart{}, keySetPart{}, slicePart{}, exceptPart{}),
+	cmp.Comparer(func(a, b *regexp.Regexp) bool {
+		return a.String() == b.String()
+	}),
+}
+
 var assertErrExpressionNotSupported assert.ErrorAssertionFunc = func(t assert.TestingT, err error, _ ...interface{}) bool {
 	return assert.ErrorIs(t, err, ErrExpressionNotSupported)
 }
@@ -37,7 +50,7 @@ func TestParse(t *testing.T) {
 			expectedPath: Path{
 				parts: []Part{
 					rootPart{},
-					mapPart{key: anyKey},
+					mapPart{wildcard: true},
 				},
 			},
 			assertErr: assert.NoError,
@@ -57,7 +70,7 @@ func TestParse(t *testing.T) {
 			expectedPath: Path{
 				parts: []Part{
 					rootPart{},
-					seqPart{index: anyIndex},
+					seqPart{wildcard: true},
 				},
 			},
 			assertErr: assert.NoError,
@@ -68,7 +81,7 @@ func TestParse(t *testing.T) {
 				parts: []Part{
 					rootPart{},
 					mapPart{key: "A_KEY"},
-					seqPart{index: anyIndex},
+					seqPart{wildcard: true},
 					mapPart{key: "other-key"},
 					mapPart{key: "thing"},
 					seqPart{index: 0},
@@ -76,6 +89,353 @@ func TestParse(t *testing.T) {
 			},
 			assertErr: assert.NoError,
 		},
+		{
+			expression: `.metadata.labels."app.kubernetes.io/name"`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "metadata"},
+					mapPart{key: "labels"},
+					mapPart{key: "app.kubernetes.io/name"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.jobs["build and test"].steps[]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "jobs"},
+					mapPart{key: "build and test"},
+					mapPart{key: "steps"},
+					seqPart{wildcard: true},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.["x.y"][0]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "x.y"},
+					seqPart{index: 0},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `."say \"hi\"\n".*`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "say \"hi\"\n"},
+					mapPart{wildcard: true},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `."*"`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "*"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: ".[0].a",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					seqPart{index: 0},
+					mapPart{key: "a"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: "..deps",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					descendantPart{},
+					mapPart{key: "deps"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.pipeline..with."x.y"[]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "pipeline"},
+					descendantPart{},
+					mapPart{key: "with"},
+					mapPart{key: "x.y"},
+					seqPart{wildcard: true},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `..[0]..["a b"]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					descendantPart{},
+					seqPart{index: 0},
+					descendantPart{},
+					mapPart{key: "a b"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: ".subpackages[name=foo-dev].dependencies",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "subpackages"},
+					filterPart{key: "name", value: "foo-dev"},
+					mapPart{key: "dependencies"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.[ "a key" = "a value" ][uses=fetch]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					filterPart{key: "a key", value: "a value"},
+					filterPart{key: "uses", value: "fetch"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.subpackages[] | select(.name == "foo-dev") | .dependencies.runtime`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "subpackages"},
+					filterPart{key: "name", value: "foo-dev"},
+					mapPart{key: "dependencies"},
+					mapPart{key: "runtime"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.pipeline[]|select(."uses"==fetch).with`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "pipeline"},
+					filterPart{key: "uses", value: "fetch"},
+					mapPart{key: "with"},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression:   `.pipeline[0] | select(.uses == "fetch")`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `.pipeline | select(.uses == "fetch")`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `.pipeline[] |`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `.pipeline[uses="fetch\q"]`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression: ".env.FOO_*.?x",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "env"},
+					newGlobPart("FOO_*"),
+					newGlobPart("?x"),
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.jobs./^build-.*/.steps./a\/b/[0]`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "jobs"},
+					regexPart{re: regexp.MustCompile(`^build-.*`)},
+					mapPart{key: "steps"},
+					regexPart{re: regexp.MustCompile(`a\/b`)},
+					seqPart{index: 0},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: `.{dependencies, devDependencies,"a.b"}..{x}`,
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					keySetPart{keys: []string{"dependencies", "devDependencies", "a.b"}},
+					descendantPart{},
+					keySetPart{keys: []string{"x"}},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression:   "./unterminated",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   "./(/",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".{a,b",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".{}",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".{a b}",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression: ".a[-1][1:][:-2][-3:4][:]",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "a"},
+					seqPart{index: -1},
+					slicePart{start: 1},
+					slicePart{end: ptr(-2)},
+					slicePart{start: -3, end: ptr(4)},
+					slicePart{},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: ".*!metadata.pipeline[]![0]![-1]",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					exceptPart{base: mapPart{wildcard: true}, except: mapPart{key: "metadata"}},
+					mapPart{key: "pipeline"},
+					exceptPart{
+						base:   exceptPart{base: seqPart{wildcard: true}, except: seqPart{index: 0}},
+						except: seqPart{index: -1},
+					},
+				},
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: "!.pipeline[0]",
+			expectedPath: Path{
+				parts: []Part{
+					rootPart{},
+					mapPart{key: "pipeline"},
+					seqPart{index: 0},
+				},
+				negated: true,
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression: "!.",
+			expectedPath: Path{
+				parts:   []Part{rootPart{}},
+				negated: true,
+			},
+			assertErr: assert.NoError,
+		},
+		{
+			expression:   "!",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".!a",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `.pipeline[]!"x.y"`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".a!..b",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".a[1:2:3]",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   "..",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   "...deps",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   ".deps..",
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `."unterminated`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `."bad \q escape"`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `.["unclosed bracket"`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
+		{
+			expression:   `."key"trailing`,
+			expectedPath: Path{},
+			assertErr:    assertErrExpressionNotSupported,
+		},
 		{
 			expression:   "no-leading-dot",
 			expectedPath: Path{},
@@ -98,9 +458,280 @@ func TestParse(t *testing.T) {
 			p, err := Parse(tt.expression)
 			tt.assertErr(t, err)
 
-			if diff := cmp.Diff(tt.expectedPath, p, cmp.AllowUnexported(Path{}, rootPart{}, mapPart{}, seqPart{})); diff != "" {
+			if diff := cmp.Diff(tt.expectedPath, p, pathCmpOptions); diff != "" {
 				t.Errorf("got unexpected value from Parse (-want, +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestPath_String(t *testing.T) {
+	cases := []struct {
+		path     Path
+		expected string
+	}{
+		{
+			path:     Root(),
+			expected: ".",
+		},
+		{
+			path:     Root().AppendMapPart("some-key").appendPart(seqPart{wildcard: true}).AppendMapPart("A_KEY").AppendSeqPart(3),
+			expected: ".some-key[].A_KEY[3]",
+		},
+		{
+			path:     Root().appendPart(mapPart{wildcard: true}).AppendMapPart("*"),
+			expected: `.*."*"`,
+		},
+		{
+			path:     Root().AppendMapPart("metadata").AppendMapPart("app.kubernetes.io/name"),
+			expected: `.metadata."app.kubernetes.io/name"`,
+		},
+		{
+			path:     Root().appendPart(descendantPart{}).AppendMapPart("deps"),
+			expected: "..deps",
+		},
+		{
+			path:     Root().AppendMapPart("a").appendPart(descendantPart{}).AppendSeqPart(0).appendPart(descendantPart{}).AppendMapPart("x.y"),
+			expected: `.a..[0].."x.y"`,
+		},
+		{
+			path:     Root().AppendMapPart("subpackages").appendPart(filterPart{key: "name", value: "foo-dev"}),
+			expected: ".subpackages[name=foo-dev]",
+		},
+		{
+			path:     Root().appendPart(filterPart{key: "a.b", value: "x y"}).appendPart(descendantPart{}).appendPart(filterPart{key: "v", value: "1.2.3"}),
+			expected: `["a.b"="x y"]..[v=1.2.3]`,
+		},
+		{
+			path:     Root().appendPart(newGlobPart("FOO_*")).AppendMapPart("FOO_*"),
+			expected: `.FOO_*."FOO_*"`,
+		},
+		{
+			path:     Root().appendPart(regexPart{re: regexp.MustCompile(`^a\/b$`)}).appendPart(keySetPart{keys: []string{"a", "b c"}}),
+			expected: `./^a\/b$/.{a,"b c"}`,
+		},
+		{
+			path:     Root().AppendMapPart("a").AppendSeqPart(-1).appendPart(slicePart{start: 1}).appendPart(slicePart{start: -3, end: ptr(-1)}).appendPart(slicePart{}),
+			expected: ".a[-1][1:][-3:-1][:]",
+		},
+		{
+			path: Root().
+				appendPart(exceptPart{base: mapPart{wildcard: true}, except: mapPart{key: "a.b"}}).
+				appendPart(exceptPart{base: seqPart{wildcard: true}, except: slicePart{end: ptr(2)}}).
+				appendPart(exceptPart{base: newGlobPart("x*"), except: keySetPart{keys: []string{"xa", "xb"}}}),
+			expected: `.*!"a.b"[]![:2].x*!{xa,xb}`,
+		},
+		{
+			path:     Path{parts: []Part{rootPart{}, mapPart{key: "pipeline"}, seqPart{index: 0}}, negated: true},
+			expected: "!.pipeline[0]",
+		},
+		{
+			path:     Path{parts: []Part{rootPart{}}, negated: true},
+			expected: "!.",
+		},
+		{
+			path:     Root().AppendMapPart("say \"hi\"\n").AppendMapPart(""),
+			expected: `."say \"hi\"\n".""`,
+		},
+		{
+			path:     Root().AppendMapPart("//any-key//").AppendSeqPart(math.MinInt64),
+			expected: `."//any-key//"[-9223372036854775808]`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.expected, func(t *testing.T) {
+			s := tt.path.String()
+			assert.Equal(t, tt.expected, s)
+
+			// The expression should parse back into the same path.
+			p, err := Parse(s)
+			assert.NoError(t, err)
+			if diff := cmp.Diff(tt.path, p, pathCmpOptions); diff != "" {
+				t.Errorf("got unexpected value from parsing String (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPath_Matches(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		subject  string
+		expected bool
+	}{
+		{pattern: ".", subject: ".", expected: true},
+		{pattern: ".a.b", subject: ".a.b", expected: true},
+		{pattern: ".a.b", subject: ".a", expected: false},
+		{pattern: ".a", subject: ".a.b", expected: false},
+		{pattern: ".*[]", subject: ".a[3]", expected: true},
+		{pattern: ".*[0]", subject: ".a[3]", expected: false},
+		{pattern: "..deps", subject: ".deps", expected: true},
+		{pattern: "..deps", subject: ".a[0].b.deps", expected: true},
+		{pattern: "..deps", subject: ".a[0].deps.b", expected: false},
+		{pattern: "..deps", subject: ".", expected: false},
+		{pattern: ".pipeline..with", subject: ".pipeline.with", expected: true},
+		{pattern: ".pipeline..with", subject: ".pipeline[2].pipeline[0].with", expected: true},
+		{pattern: ".pipeline..with", subject: ".steps[0].with", expected: false},
+		{pattern: "..with.*", subject: ".with.with.a", expected: true},
+		{pattern: "..a..a", subject: ".a", expected: false},
+		{pattern: "..a..a", subject: ".x.a.y[1].a", expected: true},
+		{pattern: "..[]", subject: ".a.b[4]", expected: true},
+		{pattern: "..[]", subject: ".a.b", expected: false},
+		{pattern: ".env.FOO_*", subject: ".env.FOO_BAR", expected: true},
+		{pattern: ".env.FOO_*", subject: ".env.FOO_", expected: true},
+		{pattern: ".env.FOO_*", subject: ".env.XFOO_BAR", expected: false},
+		{pattern: ".env.FOO_*", subject: ".env[0]", expected: false},
+		{pattern: ".env.?", subject: ".env.a", expected: true},
+		{pattern: ".env.?", subject: ".env.ab", expected: false},
+		{pattern: `.env."FOO_*"`, subject: ".env.FOO_BAR", expected: false},
+		{pattern: ".jobs./^build-.*/.steps", subject: ".jobs.build-linux.steps", expected: true},
+		{pattern: ".jobs./^build-.*/.steps", subject: ".jobs.test-build-linux.steps", expected: false},
+		{pattern: ".jobs./build/", subject: ".jobs.test-build-linux", expected: true},
+		{pattern: ".{dependencies,devDependencies}", subject: ".devDependencies", expected: true},
+		{pattern: ".{dependencies,devDependencies}", subject: ".peerDependencies", expected: false},
+		{pattern: "..{a,b}", subject: ".x[0].b", expected: true},
+		{pattern: `."*"`, subject: ".a", expected: false},
+		{pattern: `."//any-key//"`, subject: ".a", expected: false},
+		{pattern: ".a[-9223372036854775808]", subject: ".a[0]", expected: false},
+		{pattern: "!.a", subject: ".b", expected: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.pattern+" "+tt.subject, func(t *testing.T) {
+			pattern, err := Parse(tt.pattern)
+			assert.NoError(t, err)
+			subject, err := Parse(tt.subject)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expected, pattern.Matches(subject))
+		})
+	}
+}
+
+func TestPath_Matches_filters(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- name: foo-dev
+  uses: &fetch fetch
+- name: foo-doc
+  uses: *fetch
+- foo-dev
+- name:
+    nested: foo-dev
+`), &doc))
+
+	cases := []struct {
+		pattern  string
+		item     int
+		expected bool
+	}{
+		{pattern: ".[name=foo-dev]", item: 0, expected: true},
+		{pattern: ".[name=foo-dev]", item: 1, expected: false},
+		{pattern: ".[name=foo-dev]", item: 2, expected: false},
+		{pattern: ".[name=foo-dev]", item: 3, expected: false},
+		{pattern: ".[uses=fetch]", item: 1, expected: true},
+		{pattern: `.[] | select(.name == "foo-doc")`, item: 1, expected: true},
+		{pattern: "..[name=foo-dev]", item: 0, expected: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pattern, err := Parse(tt.pattern)
+			require.NoError(t, err)
+
+			subject := Root().AppendSeqItemPart(doc.Content[0], tt.item)
+			assert.Equal(t, tt.expected, pattern.Matches(subject))
+		})
+	}
+
+	t.Run("without the item", func(t *testing.T) {
+		pattern, err := Parse(".[name=foo-dev]")
+		require.NoError(t, err)
+
+		assert.False(t, pattern.Matches(Root().AppendSeqPart(0)))
+	})
+}
+
+func TestPath_Matches_indices(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("[a, b, c, d]"), &doc))
+	seq := doc.Content[0]
+
+	cases := []struct {
+		pattern  string
+		expected []bool
+	}{
+		{pattern: ".[]", expected: []bool{true, true, true, true}},
+		{pattern: ".[1]", expected: []bool{false, true, false, false}},
+		{pattern: ".[-1]", expected: []bool{false, false, false, true}},
+		{pattern: ".[-4]", expected: []bool{true, false, false, false}},
+		{pattern: ".[-5]", expected: []bool{false, false, false, false}},
+		{pattern: ".[1:]", expected: []bool{false, true, true, true}},
+		{pattern: ".[:2]", expected: []bool{true, true, false, false}},
+		{pattern: ".[1:-1]", expected: []bool{false, true, true, false}},
+		{pattern: ".[-2:]", expected: []bool{false, false, true, true}},
+		{pattern: ".[:]", expected: []bool{true, true, true, true}},
+		{pattern: ".[3:1]", expected: []bool{false, false, false, false}},
+		{pattern: ".[]![0]", expected: []bool{false, true, true, true}},
+		{pattern: ".[]![1:-1]", expected: []bool{true, false, false, true}},
+		{pattern: "!.[0]", expected: []bool{false, false, false, false}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pattern, err := Parse(tt.pattern)
+			require.NoError(t, err)
+
+			var got []bool
+			for i := range seq.Content {
+				got = append(got, pattern.Matches(Root().AppendSeqItemPart(seq, i)))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("without the length", func(t *testing.T) {
+		pattern, err := Parse(".[-1]")
+		require.NoError(t, err)
+
+		assert.False(t, pattern.Matches(Root().AppendSeqPart(3)))
+	})
+}
+
+func TestMatchesAny(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		subject  string
+		expected bool
+	}{
+		{patterns: nil, subject: ".a", expected: false},
+		{patterns: []string{".a", ".b"}, subject: ".b", expected: true},
+		{patterns: []string{".pipeline[]", "!.pipeline[0]"}, subject: ".pipeline[1]", expected: true},
+		{patterns: []string{".pipeline[]", "!.pipeline[0]"}, subject: ".pipeline[0]", expected: false},
+		{patterns: []string{"!.pipeline[0]", ".pipeline[]"}, subject: ".pipeline[0]", expected: false},
+		{patterns: []string{"!.pipeline[0]"}, subject: ".pipeline[1]", expected: false},
+		{patterns: []string{".*", "!.metadata", "!.spec"}, subject: ".status", expected: true},
+		{patterns: []string{".*", "!.metadata", "!.spec"}, subject: ".spec", expected: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(strings.Join(tt.patterns, " ")+" "+tt.subject, func(t *testing.T) {
+			var patterns []Path
+			for _, expr := range tt.patterns {
+				p, err := Parse(expr)
+				require.NoError(t, err)
+				patterns = append(patterns, p)
+			}
+
+			subject, err := Parse(tt.subject)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, MatchesAny(patterns, subject))
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,227 @@
+package formatted
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	documentStartMarker = []byte("---")
+	documentEndMarker   = []byte("...")
+)
+
+// rawDocument is a single document from a YAML stream, split up into the
+// pieces of markup that the yaml.Node representation of the document doesn't
+// retain.
+type rawDocument struct {
+	// lead holds any comments, directives and empty lines that precede the
+	// document's start marker.
+	lead []byte
+
+	// leadGap is set if the lead is separated from the previous document by
+	// empty lines.
+	leadGap bool
+
+	// start is the document's start marker line (e.g. "---" or "--- # note"),
+	// or nil if the document didn't have an explicit start marker.
+	start []byte
+
+	// body is the content of the document.
+	body []byte
+
+	// end is the document's end marker line (e.g. "..."), or nil if the
+	// document didn't have an explicit end marker.
+	end []byte
+}
+
+// EncodeStream reads every YAML document from the given io.Reader and writes
+// each document, formatted, to the encoder's io.Writer. Each document's start
+// ("---") and end ("...") markers, as well as any comments or directives that
+// precede a document's start marker, are written back as they appeared in the
+// input.
+func (enc Encoder) EncodeStream(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	// previous is the output for the previous document.
+	var previous []byte
+
+	for i, doc := range splitDocuments(b) {
+		out := new(bytes.Buffer)
+
+		// Keep one empty line between the previous document and the lead, like
+		// between the nodes of a document, unless the previous document already
+		// ends with one.
+		if doc.leadGap && !bytes.HasSuffix(previous, []byte("\n\n")) {
+			out.Write(newline)
+		}
+
+		out.Write(doc.lead)
+		out.Write(doc.start)
+
+		root := &yaml.Node{}
+		err := yaml.NewDecoder(bytes.NewReader(doc.body)).Decode(root)
+		switch {
+		case errors.Is(err, io.EOF):
+			// The document has no content, apart from any comments.
+			out.Write(doc.body)
+
+		case err != nil:
+			return fmt.Errorf("decoding document %d: %w", i+1, err)
+
+		default:
+			bodyBytes, err := enc.marshalRoot(root)
+			if err != nil {
+				return err
+			}
+			out.Write(bodyBytes)
+		}
+
+		out.Write(doc.end)
+
+		_, err = enc.w.Write(out.Bytes())
+		if err != nil {
+			return err
+		}
+
+		previous = out.Bytes()
+	}
+
+	return nil
+}
+
+// splitDocuments splits a YAML stream into its documents. Document markers are
+// only recognized at the start of a line, which is the only place the YAML spec
+// allows them.
+func splitDocuments(b []byte) []rawDocument {
+	var docs []rawDocument
+	current := rawDocument{}
+
+	for _, line := range bytes.SplitAfter(b, newline) {
+		if len(line) == 0 {
+			continue
+		}
+
+		switch {
+		case isMarkerLine(line, documentStartMarker):
+			if current.start != nil || hasContent(current.body) {
+				// This marker starts a new document. Any comments directly preceding it
+				// belong to the new document rather than the one before it.
+				var lead []byte
+				var gap bool
+				current.body, lead, gap = splitTrailingComments(current.body)
+				docs = append(docs, current)
+				current = rawDocument{lead: lead, leadGap: gap && len(lead) > 0}
+			} else {
+				current.lead = append(current.lead, current.body...)
+				current.body = nil
+
+				trimmed := trimLeadingEmptyLines(current.lead)
+				current.leadGap = len(docs) > 0 && len(trimmed) > 0 && len(trimmed) < len(current.lead)
+				current.lead = trimmed
+			}
+
+			// Anything after the marker on the same line, other than a comment, is
+			// part of the document's content.
+			marker, rest := splitMarkerLine(line)
+			current.start = marker
+			current.body = rest
+
+		case isMarkerLine(line, documentEndMarker):
+			current.end = line
+			docs = append(docs, current)
+			current = rawDocument{}
+
+		default:
+			current.body = append(current.body, line...)
+		}
+	}
+
+	if current.start != nil || len(current.lead) > 0 || len(current.body) > 0 {
+		docs = append(docs, current)
+	}
+
+	return docs
+}
+
+func isMarkerLine(line, marker []byte) bool {
+	if !bytes.HasPrefix(line, marker) {
+		return false
+	}
+
+	rest := line[len(marker):]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r'
+}
+
+func splitMarkerLine(line []byte) (marker, rest []byte) {
+	content := bytes.TrimSpace(line[len(documentStartMarker):])
+	if len(content) == 0 || content[0] == '#' {
+		return withNewline(line), nil
+	}
+
+	return withNewline(documentStartMarker), withNewline(content)
+}
+
+func withNewline(line []byte) []byte {
+	line = bytes.TrimRight(line, "\r\n")
+	return append(append([]byte{}, line...), newline...)
+}
+
+// hasContent reports whether b has any lines that aren't empty, comments, or
+// directives.
+func hasContent(b []byte) bool {
+	for _, line := range bytes.Split(b, newline) {
+		if !isNonContentLine(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNonContentLine(line []byte) bool {
+	trimmed := bytes.TrimSpace(line)
+	return len(trimmed) == 0 || trimmed[0] == '#' || line[0] == '%'
+}
+
+// splitTrailingComments splits off the block of unindented comment lines (and
+// any empty lines between them) at the end of b. Empty lines that precede the
+// block stay with the rest of b, since they may be part of a block scalar's
+// content, and gap reports whether there are any.
+func splitTrailingComments(b []byte) (rest, comments []byte, gap bool) {
+	lines := bytes.SplitAfter(b, newline)
+
+	split := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if line[0] != '#' {
+			break
+		}
+		split = i
+	}
+
+	gap = split > 0 && split < len(lines) && len(bytes.TrimSpace(lines[split-1])) == 0
+
+	return bytes.Join(lines[:split], nil), bytes.Join(lines[split:], nil), gap
+}
+
+func trimLeadingEmptyLines(b []byte) []byte {
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i == -1 || len(bytes.TrimSpace(b[:i])) > 0 {
+			break
+		}
+		b = b[i+1:]
+	}
+
+	return b
+}
@@ -0,0 +1,199 @@
+package yam
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/glob"
+	"github.com/chainguard-dev/yam/pkg/util"
+)
+
+const gitignoreFileName = ".gitignore"
+
+// ignoreFileNames lists the gitignore-style files that are read from each
+// directory, in order of increasing precedence.
+var ignoreFileNames = []string{
+	gitignoreFileName,
+	util.IgnoreFileName,
+}
+
+// ignoreRule is a single pattern line from a gitignore-style file.
+type ignoreRule struct {
+	// dir is the directory containing the file the rule came from. The rule's
+	// pattern is relative to this directory.
+	dir string
+
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher decides whether paths are ignored according to the
+// gitignore-style files found in their directories and parent directories.
+// Files are read lazily, as directories are visited.
+type ignoreMatcher struct {
+	fsys  fs.FS
+	rules map[string][]ignoreRule
+}
+
+func newIgnoreMatcher(fsys fs.FS) *ignoreMatcher {
+	return &ignoreMatcher{
+		fsys:  fsys,
+		rules: make(map[string][]ignoreRule),
+	}
+}
+
+// isIgnored reports whether the file at the given path, or any of its parent
+// directories, is ignored.
+func (m *ignoreMatcher) isIgnored(p string) (bool, error) {
+	// A path within an ignored directory is ignored, too.
+	for _, dir := range parentDirs(p)[1:] {
+		ignored, err := m.matches(dir, true)
+		if err != nil {
+			return false, err
+		}
+		if ignored {
+			return true, nil
+		}
+	}
+
+	return m.matches(p, false)
+}
+
+// matches reports whether the given path is matched by the rules from its
+// parent directories' ignore files, without considering whether any of those
+// directories are ignored themselves.
+func (m *ignoreMatcher) matches(p string, isDir bool) (bool, error) {
+	ignored := false
+
+	for _, dir := range parentDirs(p) {
+		rules, err := m.rulesFor(dir)
+		if err != nil {
+			return false, err
+		}
+
+		for _, r := range rules {
+			if r.matches(p, isDir) {
+				ignored = !r.negate
+			}
+		}
+	}
+
+	return ignored, nil
+}
+
+func (m *ignoreMatcher) rulesFor(dir string) ([]ignoreRule, error) {
+	if rules, ok := m.rules[dir]; ok {
+		return rules, nil
+	}
+
+	var rules []ignoreRule
+	for _, name := range ignoreFileNames {
+		p := path.Join(dir, name)
+		b, err := fs.ReadFile(m.fsys, p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to read %q: %w", p, err)
+		}
+
+		rules = append(rules, parseIgnoreRules(dir, b)...)
+	}
+
+	m.rules[dir] = rules
+	return rules, nil
+}
+
+// parentDirs returns the directories containing the given path, starting with
+// the root directory (".").
+func parentDirs(p string) []string {
+	var dirs []string
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+
+	return append([]string{"."}, dirs...)
+}
+
+// parseIgnoreRules parses the content of a gitignore-style file. See
+// https://git-scm.com/docs/gitignore#_pattern_format.
+func parseIgnoreRules(dir string, content []byte) []ignoreRule {
+	var rules []ignoreRule
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := trimUnescapedTrailingSpaces(strings.TrimSuffix(scanner.Text(), "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{dir: dir}
+
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// A pattern with a slash at the beginning or in the middle is relative to
+		// the ignore file's directory. Otherwise, it can match at any depth.
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		// Braces have no special meaning in gitignore patterns.
+		line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+
+		if line == "" || glob.Validate(line) != nil {
+			continue
+		}
+
+		r.pattern = line
+		rules = append(rules, r)
+	}
+
+	return rules
+}
+
+func trimUnescapedTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	return line
+}
+
+func (r ignoreRule) matches(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	rel := p
+	if r.dir != "." {
+		if !strings.HasPrefix(p, r.dir+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(p, r.dir+"/")
+	}
+
+	pattern := r.pattern
+	if !r.anchored {
+		pattern = "**/" + pattern
+	}
+
+	// The pattern was validated when it was parsed.
+	matched, _ := glob.Match(pattern, rel)
+	return matched
+}
@@ -0,0 +1,72 @@
+package yam
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":           {Data: []byte("# generated files\ngenerated/\n*.tmp.yaml\n!keep.tmp.yaml\n")},
+		"a.yaml":               {},
+		"b.tmp.yaml":           {},
+		"keep.tmp.yaml":        {},
+		"generated/g.yaml":     {},
+		"sub/.yamignore":       {Data: []byte("/local.yaml\n")},
+		"sub/.gitignore":       {Data: []byte("!b.tmp.yaml\n")},
+		"sub/b.tmp.yaml":       {},
+		"sub/local.yaml":       {},
+		"sub/s.yaml":           {},
+		"sub/deep/local.yaml":  {},
+		"sub/deep/{a,b}.yaml":  {},
+		"sub/deep/.gitignore":  {Data: []byte("{a,b}.yaml   \n")},
+		"sub/deep/other.yaml":  {},
+		"vendor/vendored.yaml": {},
+	}
+
+	cases := []struct {
+		name     string
+		options  DiscoveryOptions
+		expected []string
+	}{
+		{
+			name:    "ignore files are honored",
+			options: DiscoveryOptions{Recursive: true},
+			expected: []string{
+				"a.yaml",
+				"keep.tmp.yaml",
+				"sub/b.tmp.yaml",
+				"sub/deep/local.yaml",
+				"sub/deep/other.yaml",
+				"sub/s.yaml",
+			},
+		},
+		{
+			name:    "ignore files are disregarded",
+			options: DiscoveryOptions{Recursive: true, NoIgnore: true},
+			expected: []string{
+				"a.yaml",
+				"b.tmp.yaml",
+				"generated/g.yaml",
+				"keep.tmp.yaml",
+				"sub/b.tmp.yaml",
+				"sub/deep/local.yaml",
+				"sub/deep/other.yaml",
+				"sub/deep/{a,b}.yaml",
+				"sub/local.yaml",
+				"sub/s.yaml",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := findFiles(fsys, nil, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, files)
+		})
+	}
+}
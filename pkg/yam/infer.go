@@ -0,0 +1,279 @@
+package yam
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/yam/formatted/path"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// minSortedItems is the number of items a sequence needs to have before it
+	// being sorted is taken to be deliberate.
+	minSortedItems = 3
+
+	// minQuotedValues is the number of values a path needs to have before them
+	// all being quoted is taken to be deliberate.
+	minQuotedValues = 2
+)
+
+// InferConfig analyzes the YAML files referenced by the given paths, found in
+// the same way as for formatting, and returns a config that reproduces their
+// existing style as closely as yam can:
+//
+//   - the indent that's used most often for nested mappings and sequences
+//   - gap expressions for the nodes whose children are separated by empty
+//     lines more often than not, since yam either adds or removes them all
+//   - sort expressions for the sequences of scalars that are always sorted
+//   - quote expressions for the values that are always double-quoted
+//
+// Deduplication isn't inferred, since lists that should be deduplicated can't
+// be told apart from lists that just happen not to have duplicates.
+//
+// Files that can't be parsed are skipped. InferConfig also returns the paths of
+// the files that were analyzed.
+func InferConfig(fsys fs.FS, paths []string, options DiscoveryOptions) (FormatConfig, []string, error) {
+	files, err := findFiles(fsys, paths, options)
+	if err != nil {
+		return FormatConfig{}, nil, err
+	}
+
+	inf := newInference()
+
+	var analyzed []string
+	for _, p := range files {
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return FormatConfig{}, nil, err
+		}
+
+		if err := inf.addFile(b); err != nil {
+			continue
+		}
+
+		analyzed = append(analyzed, p)
+	}
+
+	return inf.config(), analyzed, nil
+}
+
+// pathStats collects what's been seen of the nodes at a path expression.
+type pathStats struct {
+	// boundaries counts the places where one child of a block mapping or
+	// sequence follows another, and gaps counts the ones with an empty line.
+	boundaries int
+	gaps       int
+
+	// sequences counts the sequences of scalars, and sorted counts the ones
+	// that are sorted. longest is the number of items in the longest sequence.
+	sequences int
+	sorted    int
+	longest   int
+
+	// unsortable is set if there's a sequence that has items that aren't
+	// scalars, which yam doesn't sort.
+	unsortable bool
+
+	// nodes counts all nodes, and quoted counts double-quoted scalars.
+	nodes  int
+	quoted int
+}
+
+// inference collects what's been seen of the style of YAML files.
+type inference struct {
+	// indents counts how often each indent is used.
+	indents map[int]int
+
+	paths map[string]*pathStats
+}
+
+func newInference() *inference {
+	return &inference{
+		indents: make(map[int]int),
+		paths:   make(map[string]*pathStats),
+	}
+}
+
+// addFile analyzes the content of a YAML file, which can have several
+// documents.
+func (inf *inference) addFile(b []byte) error {
+	lines := strings.Split(string(b), "\n")
+
+	// Parse all documents before using any of them, so that files that can't
+	// be parsed don't affect the result.
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		doc := new(yaml.Node)
+		err := dec.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	for _, doc := range docs {
+		for _, n := range doc.Content {
+			inf.addNode(n, ".", lines)
+		}
+	}
+
+	return nil
+}
+
+func (inf *inference) stats(expr string) *pathStats {
+	s, ok := inf.paths[expr]
+	if !ok {
+		s = new(pathStats)
+		inf.paths[expr] = s
+	}
+
+	return s
+}
+
+// addNode analyzes the node at the given path expression, and its children.
+func (inf *inference) addNode(n *yaml.Node, expr string, lines []string) {
+	s := inf.stats(expr)
+	s.nodes++
+
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Style&yaml.DoubleQuotedStyle != 0 {
+			s.quoted++
+		}
+
+	case yaml.MappingNode:
+		var starts []int
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			starts = append(starts, key.Line)
+
+			if n.Style&yaml.FlowStyle == 0 && value.Style&yaml.FlowStyle == 0 &&
+				(value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode) &&
+				len(value.Content) > 0 && value.Column > key.Column {
+				inf.indents[value.Column-key.Column]++
+			}
+
+			inf.addNode(value, childExpression(expr, key.Value), lines)
+		}
+
+		if n.Style&yaml.FlowStyle == 0 {
+			inf.addBoundaries(s, starts, lines)
+		}
+
+	case yaml.SequenceNode:
+		var starts []int
+		scalars := true
+		for _, item := range n.Content {
+			starts = append(starts, item.Line)
+			if item.Kind != yaml.ScalarNode {
+				scalars = false
+			}
+
+			inf.addNode(item, itemExpression(expr), lines)
+		}
+
+		if n.Style&yaml.FlowStyle == 0 {
+			inf.addBoundaries(s, starts, lines)
+		}
+
+		switch {
+		case !scalars:
+			s.unsortable = true
+		case len(n.Content) > 1:
+			s.sequences++
+			s.longest = max(s.longest, len(n.Content))
+			if sort.SliceIsSorted(n.Content, func(i, j int) bool {
+				return n.Content[i].Value < n.Content[j].Value
+			}) {
+				s.sorted++
+			}
+		}
+	}
+}
+
+// addBoundaries records whether there's an empty line before each child of a
+// node, other than the first, given the lines the children start on.
+func (inf *inference) addBoundaries(s *pathStats, starts []int, lines []string) {
+	for _, start := range starts[min(1, len(starts)):] {
+		s.boundaries++
+
+		// Look above any comments before the child.
+		l := start - 1
+		for l >= 1 && l <= len(lines) && strings.HasPrefix(strings.TrimSpace(lines[l-1]), "#") {
+			l--
+		}
+
+		if l >= 1 && l <= len(lines) && strings.TrimSpace(lines[l-1]) == "" {
+			s.gaps++
+		}
+	}
+}
+
+// config returns the config that reproduces what's been seen.
+func (inf *inference) config() FormatConfig {
+	var cfg FormatConfig
+
+	if len(inf.indents) > 0 {
+		indents := slices.Sorted(maps.Keys(inf.indents))
+		indent := indents[0]
+		for _, i := range indents {
+			if inf.indents[i] > inf.indents[indent] {
+				indent = i
+			}
+		}
+
+		cfg.Indent = &indent
+	}
+
+	for _, expr := range slices.Sorted(maps.Keys(inf.paths)) {
+		s := inf.paths[expr]
+
+		if s.gaps*2 > s.boundaries {
+			cfg.Gap = append(cfg.Gap, expr)
+		}
+
+		if !s.unsortable && s.sequences > 0 && s.sorted == s.sequences && s.longest >= minSortedItems {
+			cfg.Sort = append(cfg.Sort, expr)
+		}
+
+		if s.nodes >= minQuotedValues && s.quoted == s.nodes {
+			cfg.Quote = append(cfg.Quote, expr)
+		}
+	}
+
+	return cfg
+}
+
+// childExpression returns the path expression for the value of the given key
+// in the mapping at expr. Keys with special characters are quoted.
+func childExpression(expr, key string) string {
+	child := path.Root().AppendMapPart(key).String()
+
+	if expr == "." {
+		return child
+	}
+
+	return expr + child
+}
+
+// itemExpression returns the path expression for the items of the sequence at
+// expr.
+func itemExpression(expr string) string {
+	if expr == "." {
+		return ".[]"
+	}
+
+	return expr + "[]"
+}
@@ -0,0 +1,122 @@
+package yam
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInferConfig(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte(`name: a
+
+packages:
+    - "bash"
+    - "busybox"
+    - "curl"
+
+steps:
+    - uses: checkout
+
+    # Build it.
+    - runs: make
+`)},
+		"nested/b.yaml": {Data: []byte(`name: b
+
+packages:
+    - "git"
+    - "make"
+    - "zlib"
+---
+name: c
+
+environment:
+    contents:
+        packages: [zlib, bash]
+`)},
+		"nested/c.yaml": {Data: []byte(`name: [broken
+`)},
+		"d.yaml": {Data: []byte(`name: d
+steps:
+    - runs: a
+    - runs: b
+    - runs: c
+`)},
+	}
+
+	cfg, files, err := InferConfig(fsys, nil, DiscoveryOptions{Recursive: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a.yaml", "d.yaml", "nested/b.yaml"}, files)
+
+	expected := FormatConfig{
+		Indent: ptr(4),
+		Gap:    []string{"."},
+		Sort:   []string{".packages"},
+		Quote:  []string{".packages[]"},
+	}
+	assert.Equal(t, expected, cfg)
+}
+
+func TestInferConfig_specialKeys(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte(`metadata:
+  labels:
+    app.kubernetes.io/name: "a"
+`)},
+		"b.yaml": {Data: []byte(`metadata:
+  labels:
+    app.kubernetes.io/name: "b"
+`)},
+	}
+
+	cfg, _, err := InferConfig(fsys, nil, DiscoveryOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`.metadata.labels."app.kubernetes.io/name"`}, cfg.Quote)
+}
+
+func TestInferConfig_noNesting(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte("a: 1\nb: 2\n")},
+	}
+
+	cfg, _, err := InferConfig(fsys, nil, DiscoveryOptions{})
+	require.NoError(t, err)
+
+	// Nothing is inferred, so the defaults are used.
+	assert.Equal(t, FormatConfig{}, cfg)
+}
+
+func TestFormatConfig_MarshalYAML(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(`indent: 4
+gap: !reset
+  - .
+final-newline: false
+overrides:
+  - files:
+      - "*.k8s.yaml"
+    sort:
+      - .b
+`), "", t.TempDir())
+	require.NoError(t, err)
+
+	b, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+
+	expected := `indent: 4
+gap: !reset
+    - .
+final-newline: false
+overrides:
+    - files:
+        - '*.k8s.yaml'
+      sort:
+        - .b
+`
+	assert.Equal(t, expected, string(b))
+}
@@ -8,7 +8,6 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/chainguard-dev/yam/pkg/util"
 )
@@ -17,137 +16,199 @@ var (
 	ErrDidNotPassLintCheck = errors.New("input did not pass the lint check")
 )
 
+// LintResult describes the outcome of linting a single file.
+type LintResult struct {
+	// Path is the path of the linted file.
+	Path string
+
+	// Original is the file's content.
+	Original []byte
+
+	// Formatted is the file's content after formatting.
+	Formatted []byte
+
+	// Violations lists the parts of the file that aren't formatted as expected.
+	// It's empty if the file passed the lint check.
+	Violations []Violation
+}
+
+// Passed reports whether the file passed the lint check.
+func (r LintResult) Passed() bool {
+	return len(r.Violations) == 0
+}
+
 func Lint(fsys fs.FS, paths []string, handler DiffHandler, options FormatOptions) error {
-	// "No paths" means "look at all files in the current directory".
-	if len(paths) == 0 {
-		paths = append(paths, ".")
+	results, err := LintFiles(fsys, paths, options)
+	var fileErr *FileError
+	if err != nil && !errors.As(err, &fileErr) {
+		return err
 	}
 
-	var pathsThatFailedLinting []string
+	// Report the files that could be linted before the ones that couldn't.
+	return errors.Join(handleLintResults(results, handler), err)
+}
 
-	for _, p := range paths {
-		stat, err := fs.Stat(fsys, p)
-		if err != nil {
-			return err
-		}
+// LintFiles lints the YAML files referenced by the given paths, and returns a
+// result for each file, regardless of whether it passed the lint check. Files
+// are linted concurrently, as configured by the options' Jobs field, but the
+// results are in the order the files were found.
+//
+// As with FormatFiles, unless the options' FailFast field is set, a FileErrors
+// is returned for files that can't be linted, along with the results for the
+// other files. With FailFast, the *FileError for the first file that can't be
+// linted is returned along with the results for the files linted before it.
+func LintFiles(fsys fs.FS, paths []string, options FormatOptions) ([]LintResult, error) {
+	files, err := findFiles(fsys, paths, options.DiscoveryOptions)
+	if err != nil {
+		return nil, err
+	}
 
-		if stat.IsDir() {
-			errLint := lintDir(fsys, p, handler, options)
-			if errLint != nil {
-				var e errLintCheckFailed
-				if errors.As(errLint, &e) {
-					pathsThatFailedLinting = append(pathsThatFailedLinting, e.paths...)
-					continue
-				}
-
-				// An error more serious than lint check failure has occurred.
-				return errLint
-			}
+	return processFiles(files, options.Jobs, options.FailFast, func(p string) (*LintResult, error) {
+		return lintSingleFile(fsys, p, options)
+	})
+}
 
-			continue
-		}
+// LintReader checks whether the YAML read from r is formatted according to the
+// given options, and returns ErrDidNotPassLintCheck if it isn't. Any diff is
+// passed to the handler.
+//
+// If name is non-empty, it's used as the path of the input within fsys, both
+// for reporting and to decide whether the input should be skipped because of
+// ignore files or the options' include and exclude patterns. Input that's
+// skipped always passes the lint check.
+func LintReader(fsys fs.FS, name string, r io.Reader, handler DiffHandler, options FormatOptions) error {
+	results, err := LintReaderResults(fsys, name, r, options)
+	if err != nil {
+		return err
+	}
 
-		err = lintSingleFile(fsys, p, handler, options)
-		if err != nil {
-			if errors.As(err, &errLintCheckFailed{}) {
-				pathsThatFailedLinting = append(pathsThatFailedLinting, p)
-				continue
-			}
+	return handleLintResults(results, handler)
+}
 
-			// An error more serious than lint check failure has occurred.
-			return err
+// LintReaderResults is like LintReader, but rather than handling diffs, it
+// returns the result of linting the input. No results are returned if the
+// input was skipped.
+func LintReaderResults(fsys fs.FS, name string, r io.Reader, options FormatOptions) ([]LintResult, error) {
+	displayName := stdinDisplayName
+	if name != "" {
+		skip, err := isSkipped(fsys, name, options.DiscoveryOptions)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return nil, nil
 		}
-	}
 
-	if len(pathsThatFailedLinting) >= 1 {
-		return ErrDidNotPassLintCheck
+		displayName = name
 	}
 
-	return nil
-}
+	options, err := options.forFile(name)
+	if err != nil {
+		return nil, err
+	}
 
-func lintDir(fsys fs.FS, dirPath string, handler DiffHandler, options FormatOptions) error {
-	dirEntries, err := fs.ReadDir(fsys, dirPath)
+	result, err := lintContent(displayName, r, options)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var dirEntriesThatFailedLinting []string
+	return []LintResult{result}, nil
+}
+
+// handleLintResults reports each failed result to stderr and the given
+// handler, and returns ErrDidNotPassLintCheck if any result failed. Results are
+// handled one at a time, in order, so that the output for different files
+// never interleaves.
+func handleLintResults(results []LintResult, handler DiffHandler) error {
+	failed := false
 
-	for _, file := range dirEntries {
-		if !file.Type().IsRegular() {
+	for _, r := range results {
+		if r.Passed() {
 			continue
 		}
 
-		p := filepath.Join(dirPath, file.Name())
-		errSingleFile := lintSingleFile(fsys, p, handler, options)
-		if errSingleFile != nil {
-			if errors.As(errSingleFile, &errLintCheckFailed{}) {
-				dirEntriesThatFailedLinting = append(dirEntriesThatFailedLinting, p)
-				continue
-			}
+		failed = true
+		fmt.Fprintf(os.Stderr, "%s has a diff from the expected formatting\n", r.Path)
 
-			return errSingleFile
+		if handler != nil {
+			errHandler := handler(r.Formatted, r.Original)
+			if errHandler != nil {
+				return fmt.Errorf("unable to handle diff: %w", errHandler)
+			}
 		}
 	}
 
-	if len(dirEntriesThatFailedLinting) >= 1 {
-		return newErrLintCheckFailed(dirEntriesThatFailedLinting...)
+	if failed {
+		return ErrDidNotPassLintCheck
 	}
 
 	return nil
 }
 
-func lintSingleFile(fsys fs.FS, path string, handler DiffHandler, options FormatOptions) error {
+func lintSingleFile(fsys fs.FS, path string, options FormatOptions) (*LintResult, error) {
 	// Immediately skip files that aren't YAML files
 	if !util.IsYAML(path) {
-		return nil
+		return nil, nil
+	}
+
+	options, err := options.forFile(path)
+	if err != nil {
+		return nil, err
 	}
 
 	cleaned := filepath.Clean(path)
 	file, err := fsys.Open(cleaned)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Save the original content for comparison after applying the formatting.
-	original := new(bytes.Buffer)
-	tee := io.TeeReader(file, original)
-
 	defer file.Close()
 
-	formatted, err := applyFormatting(tee, options)
+	result, err := lintContent(path, file, options)
 	if err != nil {
-		return fmt.Errorf("unable to format %q: %w", path, err)
+		return nil, err
 	}
 
-	want := formatted.Bytes()
-	got := original.Bytes()
+	return &result, nil
+}
 
-	if !bytes.Equal(want, got) {
-		fmt.Fprintf(os.Stderr, "%s has a diff from the expected formatting\n", path)
+func lintContent(path string, r io.Reader, options FormatOptions) (LintResult, error) {
+	original, err := io.ReadAll(r)
+	if err != nil {
+		return LintResult{}, fmt.Errorf("unable to read %q: %w", path, err)
+	}
 
-		if handler != nil {
-			errHandler := handler(want, got)
-			if errHandler != nil {
-				return fmt.Errorf("unable to handle diff: %w", errHandler)
-			}
+	result := LintResult{
+		Path:      path,
+		Original:  original,
+		Formatted: original,
+	}
+
+	var cacheOpts []byte
+	if options.Cache != nil {
+		cacheOpts, err = cacheOptions(options)
+		if err != nil {
+			return LintResult{}, err
 		}
 
-		return newErrLintCheckFailed(path)
+		if options.Cache.Contains(original, cacheOpts) {
+			return result, nil
+		}
 	}
 
-	return nil
-}
+	formatted, err := applyFormatting(bytes.NewReader(original), options)
+	if err != nil {
+		return LintResult{}, fmt.Errorf("unable to format %q: %w", path, err)
+	}
 
-type errLintCheckFailed struct {
-	paths []string
-}
+	result.Formatted = formatted.Bytes()
 
-func newErrLintCheckFailed(paths ...string) errLintCheckFailed {
-	return errLintCheckFailed{paths: paths}
-}
+	if !bytes.Equal(result.Formatted, result.Original) {
+		result.Violations = findViolations(result.Original, result.Formatted)
+	} else if options.Cache != nil {
+		// The cache is only an optimization, so failing to update it is fine.
+		_ = options.Cache.Add(original, cacheOpts)
+	}
 
-func (e errLintCheckFailed) Error() string {
-	return fmt.Sprintf("the following was not formatted correctly: %s", strings.Join(e.paths, ", "))
+	return result, nil
 }
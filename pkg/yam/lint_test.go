@@ -1,7 +1,9 @@
 package yam
 
 import (
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/chainguard-dev/yam/pkg/rwfs/os"
 	"github.com/chainguard-dev/yam/pkg/yam/formatted"
@@ -77,7 +79,51 @@ func TestLint(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			fsys := os.DirFS("testdata/lint")
 
-			err := Lint(fsys, tt.paths, ExecDiff, tt.opts)
+			err := Lint(fsys, tt.paths, UnifiedDiff, tt.opts)
+			tt.assertErr(t, err)
+		})
+	}
+}
+
+func TestLintReader(t *testing.T) {
+	opts := FormatOptions{
+		EncodeOptions: formatted.EncodeOptions{
+			Indent: 2,
+		},
+		FinalNewline:           true,
+		TrimTrailingWhitespace: true,
+		DiscoveryOptions: DiscoveryOptions{
+			Exclude: []string{"excluded/**"},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		fileName  string
+		input     string
+		assertErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "correct input",
+			input:     "a: 1\n",
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "incorrect input",
+			input:     "a:   1\n",
+			assertErr: didNotPassLintCheck,
+		},
+		{
+			name:      "excluded input",
+			fileName:  "excluded/file.yaml",
+			input:     "a:   1\n",
+			assertErr: assert.NoError,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LintReader(fstest.MapFS{}, tt.fileName, strings.NewReader(tt.input), nil, opts)
 			tt.assertErr(t, err)
 		})
 	}
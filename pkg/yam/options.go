@@ -1,6 +1,10 @@
 package yam
 
-import "github.com/chainguard-dev/yam/pkg/yam/formatted"
+import (
+	"fmt"
+
+	"github.com/chainguard-dev/yam/pkg/yam/formatted"
+)
 
 type FormatOptions struct {
 	// EncodeOptions specifies the encoder-specific format options.
@@ -13,4 +17,68 @@ type FormatOptions struct {
 	// TrimTrailingWhitespace specifies whether to trim any trailing space
 	// characters from each line before further formatting is applied.
 	TrimTrailingWhitespace bool `mapstructure:"trim-lines"`
+
+	// DiscoveryOptions specifies how YAML files are found within directories.
+	DiscoveryOptions DiscoveryOptions
+
+	// Jobs specifies the maximum number of files to process at once. If it's
+	// not positive, runtime.GOMAXPROCS(0) is used.
+	Jobs int
+
+	// FailFast specifies whether to stop processing files as soon as any file
+	// can't be processed. By default, the remaining files are still processed,
+	// and the errors for all failed files are returned together.
+	FailFast bool
+
+	// Cache, if set, is used to skip files whose content is already known to be
+	// formatted, and to record the files that turn out to be formatted.
+	Cache Cache
+
+	// OptionsForFile, if set, returns the options to use for the file at the
+	// given path, e.g. from the config files that apply to it. Only the fields
+	// that affect how content is formatted (EncodeOptions, FinalNewline and
+	// TrimTrailingWhitespace) are taken from the returned options. The path is
+	// empty for input that doesn't come from a named file.
+	OptionsForFile func(path string) (FormatOptions, error)
+}
+
+// forFile returns the options to use for the file at the given path.
+func (options FormatOptions) forFile(path string) (FormatOptions, error) {
+	if options.OptionsForFile == nil {
+		return options, nil
+	}
+
+	fileOptions, err := options.OptionsForFile(path)
+	if err != nil {
+		return FormatOptions{}, fmt.Errorf("unable to determine options for %q: %w", path, err)
+	}
+
+	resolved := options
+	resolved.EncodeOptions = fileOptions.EncodeOptions
+	resolved.FinalNewline = fileOptions.FinalNewline
+	resolved.TrimTrailingWhitespace = fileOptions.TrimTrailingWhitespace
+	resolved.OptionsForFile = nil
+
+	return resolved, nil
+}
+
+// DiscoveryOptions describes how yam finds the YAML files to process when it's
+// given a directory.
+type DiscoveryOptions struct {
+	// Recursive specifies whether to look for YAML files in subdirectories, too,
+	// rather than just the given directory.
+	Recursive bool `yaml:"recursive"`
+
+	// Include specifies a list of doublestar glob patterns (e.g. "**/*.yaml"). If
+	// set, only files whose paths match at least one pattern are processed.
+	Include []string `yaml:"include"`
+
+	// Exclude specifies a list of doublestar glob patterns for files and
+	// directories to skip. Directories named ".git" or "vendor" are always
+	// skipped.
+	Exclude []string `yaml:"exclude"`
+
+	// NoIgnore specifies whether to disregard .gitignore and .yamignore files.
+	// By default, files and directories matched by these files are skipped.
+	NoIgnore bool `yaml:"-"`
 }
@@ -0,0 +1,82 @@
+package yam
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// processFiles calls fn for each of the given files, using up to the given
+// number of goroutines at once, or runtime.GOMAXPROCS(0) if jobs isn't
+// positive. It returns the results that aren't nil in the same order as the
+// files they came from, regardless of the order in which fn finished.
+//
+// If fn returns an error for any files, processFiles returns a FileErrors with
+// those errors, along with the results for the other files. If failFast is
+// true, no further files are processed once fn returns an error, and only the
+// error for the earliest of the files that failed is returned, as a *FileError,
+// along with the results for the files that were processed before it stopped.
+func processFiles[T any](files []string, jobs int, failFast bool, fn func(path string) (*T, error)) ([]T, error) {
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	jobs = min(jobs, len(files))
+
+	results := make([]*T, len(files))
+	errs := make([]error, len(files))
+
+	var failed atomic.Bool
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				if failFast && failed.Load() {
+					continue
+				}
+
+				results[i], errs[i] = fn(files[i])
+				if errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	out := make([]T, 0, len(files))
+	for _, r := range results {
+		if r != nil {
+			out = append(out, *r)
+		}
+	}
+
+	var fileErrs FileErrors
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		fe := &FileError{Path: files[i], Err: err}
+		if failFast {
+			return out, fe
+		}
+
+		fileErrs = append(fileErrs, fe)
+	}
+
+	if len(fileErrs) > 0 {
+		return out, fileErrs
+	}
+
+	return out, nil
+}
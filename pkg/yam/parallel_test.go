@@ -0,0 +1,76 @@
+package yam
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_processFiles(t *testing.T) {
+	var files []string
+	for i := range 50 {
+		files = append(files, fmt.Sprintf("file-%02d.yaml", i))
+	}
+
+	t.Run("results are in order", func(t *testing.T) {
+		results, err := processFiles(files, 8, false, func(p string) (*string, error) {
+			// Finish in a random order.
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			return &p, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, files, results)
+	})
+
+	t.Run("nil results are dropped", func(t *testing.T) {
+		results, err := processFiles(files, 0, false, func(p string) (*string, error) {
+			if p != "file-07.yaml" {
+				return nil, nil
+			}
+			return &p, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file-07.yaml"}, results)
+	})
+
+	t.Run("errors are collected", func(t *testing.T) {
+		results, err := processFiles(files, 4, false, func(p string) (*string, error) {
+			if p == "file-10.yaml" || p == "file-30.yaml" {
+				return nil, errors.New("bad " + p)
+			}
+			return &p, nil
+		})
+
+		var fileErrs FileErrors
+		require.ErrorAs(t, err, &fileErrs)
+		require.Len(t, fileErrs, 2)
+		assert.Equal(t, "file-10.yaml", fileErrs[0].Path)
+		assert.Equal(t, "file-30.yaml", fileErrs[1].Path)
+		assert.EqualError(t, err, "unable to process 2 files:\n  bad file-10.yaml\n  bad file-30.yaml")
+
+		// The other files are still processed.
+		assert.Len(t, results, len(files)-2)
+	})
+
+	t.Run("fail fast returns the earliest error", func(t *testing.T) {
+		results, err := processFiles(files, 1, true, func(p string) (*string, error) {
+			if p >= "file-10.yaml" {
+				return nil, errors.New(p)
+			}
+			return &p, nil
+		})
+
+		var fileErr *FileError
+		require.ErrorAs(t, err, &fileErr)
+		assert.Equal(t, "file-10.yaml", fileErr.Path)
+		assert.EqualError(t, err, "file-10.yaml")
+
+		// The files processed before the failure still have results.
+		assert.Equal(t, files[:10], results)
+	})
+}
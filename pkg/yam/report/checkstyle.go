@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+const checkstyleVersion = "4.3"
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeCheckstyle(w io.Writer, results []yam.LintResult) error {
+	report := checkstyleReport{
+		Version: checkstyleVersion,
+	}
+
+	for _, r := range results {
+		f := checkstyleFile{Name: r.Path}
+
+		for _, v := range r.Violations {
+			f.Errors = append(f.Errors, checkstyleError{
+				Line:     max(v.StartLine, 1),
+				Severity: "error",
+				Message:  violationText(v),
+				Source:   toolName + "." + v.Setting,
+			})
+		}
+
+		report.Files = append(report.Files, f)
+	}
+
+	return writeXML(w, report)
+}
+
+// violationText describes the violation, including its suggested replacement,
+// for formats that only have room for a plain text message.
+func violationText(v yam.Violation) string {
+	lines := fmt.Sprintf("line %d", max(v.StartLine, 1))
+	if v.EndLine > v.StartLine {
+		lines = fmt.Sprintf("lines %d-%d", v.StartLine, v.EndLine)
+	}
+
+	return fmt.Sprintf("%s: %s", lines, violationDescription(v))
+}
+
+// violationDescription describes the violation and its suggested replacement,
+// without saying which lines it covers.
+func violationDescription(v yam.Violation) string {
+	return fmt.Sprintf("%s (%s); expected:\n%s", v.Message(), v.Setting, v.Replacement)
+}
+
+func writeXML(w io.Writer, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+// writeGitHub writes a GitHub Actions "error" workflow command for each
+// violation, which GitHub shows as an annotation on the violation's lines. See
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions.
+func writeGitHub(w io.Writer, results []yam.LintResult) error {
+	sb := new(strings.Builder)
+
+	for _, r := range results {
+		for _, v := range r.Violations {
+			line := max(v.StartLine, 1)
+
+			fmt.Fprintf(sb, "::error file=%s,line=%d,endLine=%d,title=%s::%s\n",
+				escapeGitHubProperty(r.Path),
+				line,
+				max(v.EndLine, line),
+				escapeGitHubProperty(toolName+" "+v.Setting),
+				escapeGitHubData(violationDescription(v)),
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+	).Replace(s)
+}
+
+// escapeGitHubProperty escapes the value of a workflow command's property.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+		":", "%3A",
+		",", "%2C",
+	).Replace(s)
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+// gitlabIssue is an issue in a GitLab Code Quality report. See
+// https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool.
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+func writeGitLab(w io.Writer, results []yam.LintResult) error {
+	issues := []gitlabIssue{}
+
+	for _, r := range results {
+		// occurrences counts the violations seen so far by fingerprint, so that
+		// identical violations in the same file get different fingerprints.
+		occurrences := make(map[string]int)
+
+		for _, v := range r.Violations {
+			begin := max(v.StartLine, 1)
+
+			fingerprint := gitlabFingerprint(r.Path, v)
+			occurrences[fingerprint]++
+			if n := occurrences[fingerprint]; n > 1 {
+				fingerprint = gitlabFingerprintOccurrence(fingerprint, n)
+			}
+
+			issues = append(issues, gitlabIssue{
+				Description: violationDescription(v),
+				CheckName:   toolName + "." + v.Setting,
+				Fingerprint: fingerprint,
+				Severity:    "minor",
+				Location: gitlabLocation{
+					Path: r.Path,
+					Lines: gitlabLines{
+						Begin: begin,
+						End:   max(v.EndLine, begin),
+					},
+				},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// gitlabFingerprint returns an identifier for the violation that stays the same
+// across runs while the violation remains unfixed, even if lines above it are
+// added or removed. It's based on the file's path, the violation's setting, and
+// the offending and expected content, so identical violations in the same file
+// share it; see gitlabFingerprintOccurrence.
+func gitlabFingerprint(path string, v yam.Violation) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s", path, v.Setting, v.Original, v.Replacement)))
+	return hex.EncodeToString(sum[:])
+}
+
+// gitlabFingerprintOccurrence returns the fingerprint for the nth occurrence of
+// a violation with the given fingerprint in a file, since GitLab needs the
+// fingerprints in a report to be unique.
+func gitlabFingerprintOccurrence(fingerprint string, n int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", fingerprint, n)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+type jsonReport struct {
+	Files []jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	Path       string          `json:"path"`
+	Passed     bool            `json:"passed"`
+	Violations []jsonViolation `json:"violations"`
+}
+
+type jsonViolation struct {
+	StartLine   int    `json:"startLine"`
+	EndLine     int    `json:"endLine"`
+	Setting     string `json:"setting"`
+	Message     string `json:"message"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+func writeJSON(w io.Writer, results []yam.LintResult) error {
+	report := jsonReport{
+		Files: make([]jsonFile, 0, len(results)),
+	}
+
+	for _, r := range results {
+		f := jsonFile{
+			Path:       r.Path,
+			Passed:     r.Passed(),
+			Violations: make([]jsonViolation, 0, len(r.Violations)),
+		}
+
+		for _, v := range r.Violations {
+			f.Violations = append(f.Violations, jsonViolation{
+				StartLine:   v.StartLine,
+				EndLine:     v.EndLine,
+				Setting:     v.Setting,
+				Message:     v.Message(),
+				Original:    v.Original,
+				Replacement: v.Replacement,
+			})
+		}
+
+		report.Files = append(report.Files, f)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
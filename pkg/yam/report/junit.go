@@ -0,0 +1,78 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+const junitClassName = "yam.lint"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, results []yam.LintResult) error {
+	failures := failureCount(results)
+
+	suite := junitTestSuite{
+		Name:     toolName,
+		Tests:    len(results),
+		Failures: failures,
+	}
+
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Path,
+			ClassName: junitClassName,
+		}
+
+		if !r.Passed() {
+			var details []string
+			for _, v := range r.Violations {
+				details = append(details, violationText(v))
+			}
+
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s has %d formatting violation(s)", r.Path, len(r.Violations)),
+				Type:    toolName + ".formatting",
+				Text:    strings.Join(details, "\n"),
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	return writeXML(w, junitTestSuites{
+		Name:     toolName,
+		Tests:    len(results),
+		Failures: failures,
+		Suites:   []junitTestSuite{suite},
+	})
+}
@@ -0,0 +1,92 @@
+// Package report writes the results of linting YAML files in formats that can
+// be consumed by other tools.
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+// Format is the name of a report format.
+type Format string
+
+const (
+	// FormatText isn't a structured report format; it means lint failures should
+	// be described with plain text and diffs.
+	FormatText Format = "text"
+
+	FormatJSON       Format = "json"
+	FormatSARIF      Format = "sarif"
+	FormatCheckstyle Format = "checkstyle"
+	FormatJUnit      Format = "junit"
+
+	// FormatGitHub writes GitHub Actions workflow commands, which annotate the
+	// lines of each violation in the GitHub UI.
+	FormatGitHub Format = "github"
+
+	// FormatGitLab writes a GitLab Code Quality report.
+	FormatGitLab Format = "gitlab"
+)
+
+const (
+	toolName = "yam"
+	toolURI  = "https://github.com/chainguard-dev/yam"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{
+	FormatText,
+	FormatJSON,
+	FormatSARIF,
+	FormatCheckstyle,
+	FormatJUnit,
+	FormatGitHub,
+	FormatGitLab,
+}
+
+// ParseFormat returns the Format with the given name, or an error if the
+// format isn't supported.
+func ParseFormat(name string) (Format, error) {
+	f := Format(name)
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unsupported output format %q (supported formats: %v)", name, Formats)
+	}
+
+	return f, nil
+}
+
+// Write writes a report of the given lint results to w, using the given
+// structured format.
+func Write(w io.Writer, format Format, results []yam.LintResult) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, results)
+	case FormatSARIF:
+		return writeSARIF(w, results)
+	case FormatCheckstyle:
+		return writeCheckstyle(w, results)
+	case FormatJUnit:
+		return writeJUnit(w, results)
+	case FormatGitHub:
+		return writeGitHub(w, results)
+	case FormatGitLab:
+		return writeGitLab(w, results)
+	}
+
+	return fmt.Errorf("format %q isn't a structured report format", format)
+}
+
+// failureCount returns the number of results that didn't pass the lint check.
+func failureCount(results []yam.LintResult) int {
+	count := 0
+	for _, r := range results {
+		if !r.Passed() {
+			count++
+		}
+	}
+
+	return count
+}
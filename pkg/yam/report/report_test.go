@@ -0,0 +1,214 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/chainguard-dev/yam/pkg/yam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testResults = []yam.LintResult{
+	{
+		Path: "good.yaml",
+	},
+	{
+		Path:     "bad.yaml",
+		Original: []byte("a:\n- b\n- a\nc: 1\nd: 2\n"),
+		Violations: []yam.Violation{
+			{StartLine: 2, EndLine: 3, Setting: yam.SettingSort, Original: "- b\n- a\n", Replacement: "- a\n- b\n"},
+			{StartLine: 4, EndLine: 4, Setting: yam.SettingGap, Original: "", Replacement: "\n"},
+		},
+	},
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		parsed, err := ParseFormat(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, parsed)
+	}
+
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestWrite_text(t *testing.T) {
+	err := Write(new(bytes.Buffer), FormatText, testResults)
+	assert.Error(t, err)
+}
+
+func TestWrite_json(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatJSON, testResults))
+
+	var report jsonReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+
+	expected := jsonReport{
+		Files: []jsonFile{
+			{
+				Path:       "good.yaml",
+				Passed:     true,
+				Violations: []jsonViolation{},
+			},
+			{
+				Path:   "bad.yaml",
+				Passed: false,
+				Violations: []jsonViolation{
+					{StartLine: 2, EndLine: 3, Setting: "sort", Message: "items aren't sorted", Original: "- b\n- a\n", Replacement: "- a\n- b\n"},
+					{StartLine: 4, EndLine: 4, Setting: "gap", Message: "empty lines don't match the configured gaps", Original: "", Replacement: "\n"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, report)
+}
+
+func TestWrite_sarif(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatSARIF, testResults))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	var ruleIDs []string
+	for _, r := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, r.ID)
+	}
+	assert.Equal(t, []string{"sort", "gap"}, ruleIDs)
+
+	require.Len(t, run.Results, 2)
+
+	sortResult := run.Results[0]
+	assert.Equal(t, "sort", sortResult.RuleID)
+	assert.Equal(t, "bad.yaml", sortResult.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, sarifRegion{StartLine: 2, EndLine: 3}, sortResult.Locations[0].PhysicalLocation.Region)
+
+	replacement := sortResult.Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, sarifRegion{StartLine: 2, StartColumn: 1, EndLine: 4, EndColumn: 1}, replacement.DeletedRegion)
+	assert.Equal(t, "- a\n- b\n", replacement.InsertedContent.Text)
+
+	// Insertions replace an empty region at the start of the following line.
+	gapReplacement := run.Results[1].Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, sarifRegion{StartLine: 5, StartColumn: 1, EndLine: 5, EndColumn: 1}, gapReplacement.DeletedRegion)
+}
+
+func TestWrite_sarifEndOfFile(t *testing.T) {
+	results := []yam.LintResult{{
+		Path:     "end.yaml",
+		Original: []byte("a: 1\nb: [x, y] ü\n"),
+		Violations: []yam.Violation{
+			{StartLine: 2, EndLine: 2, Setting: yam.SettingFormatting, Original: "b: [x, y] ü\n", Replacement: ""},
+			{StartLine: 2, EndLine: 2, Setting: yam.SettingFinalNewline, Original: "", Replacement: "\n"},
+		},
+	}}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatSARIF, results))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs[0].Results, 2)
+
+	// Regions that would end after the last line end at the end of the last
+	// line instead.
+	deletion := log.Runs[0].Results[0].Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, sarifRegion{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 13}, deletion.DeletedRegion)
+
+	insertion := log.Runs[0].Results[1].Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, sarifRegion{StartLine: 2, StartColumn: 13, EndLine: 2, EndColumn: 13}, insertion.DeletedRegion)
+}
+
+func TestWrite_checkstyle(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatCheckstyle, testResults))
+
+	var report checkstyleReport
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &report))
+
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, "good.yaml", report.Files[0].Name)
+	assert.Empty(t, report.Files[0].Errors)
+
+	require.Len(t, report.Files[1].Errors, 2)
+	e := report.Files[1].Errors[0]
+	assert.Equal(t, 2, e.Line)
+	assert.Equal(t, "yam.sort", e.Source)
+	assert.Equal(t, "lines 2-3: items aren't sorted (sort); expected:\n- a\n- b\n", e.Message)
+}
+
+func TestWrite_junit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatJUnit, testResults))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+
+	require.Len(t, suites.Suites, 1)
+	cases := suites.Suites[0].TestCases
+	require.Len(t, cases, 2)
+	assert.Nil(t, cases[0].Failure)
+	require.NotNil(t, cases[1].Failure)
+	assert.Equal(t, "bad.yaml has 2 formatting violation(s)", cases[1].Failure.Message)
+}
+
+func TestWrite_github(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatGitHub, testResults))
+
+	expected := "::error file=bad.yaml,line=2,endLine=3,title=yam sort::items aren't sorted (sort); expected:%0A- a%0A- b%0A\n" +
+		"::error file=bad.yaml,line=4,endLine=4,title=yam gap::empty lines don't match the configured gaps (gap); expected:%0A%0A\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_escapeGitHubProperty(t *testing.T) {
+	assert.Equal(t, "a%3Ab%2Cc%25d%0Ae", escapeGitHubProperty("a:b,c%d\ne"))
+}
+
+func TestWrite_gitlab(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, FormatGitLab, testResults))
+
+	var issues []gitlabIssue
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &issues))
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, "yam.sort", issues[0].CheckName)
+	assert.Equal(t, gitlabLocation{Path: "bad.yaml", Lines: gitlabLines{Begin: 2, End: 3}}, issues[0].Location)
+	assert.Equal(t, gitlabLocation{Path: "bad.yaml", Lines: gitlabLines{Begin: 4, End: 4}}, issues[1].Location)
+	assert.NotEqual(t, issues[0].Fingerprint, issues[1].Fingerprint)
+
+	// Fingerprints don't change when the violations move to other lines, and
+	// identical violations get different ones.
+	moved := []yam.LintResult{{
+		Path: "bad.yaml",
+		Violations: []yam.Violation{
+			{StartLine: 12, EndLine: 13, Setting: yam.SettingSort, Original: "- b\n- a\n", Replacement: "- a\n- b\n"},
+			{StartLine: 20, EndLine: 21, Setting: yam.SettingSort, Original: "- b\n- a\n", Replacement: "- a\n- b\n"},
+		},
+	}}
+
+	buf.Reset()
+	require.NoError(t, Write(buf, FormatGitLab, moved))
+
+	var movedIssues []gitlabIssue
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &movedIssues))
+	require.Len(t, movedIssues, 2)
+	assert.Equal(t, issues[0].Fingerprint, movedIssues[0].Fingerprint)
+	assert.NotEqual(t, movedIssues[0].Fingerprint, movedIssues[1].Fingerprint)
+
+	// An empty report is still a valid (empty) list of issues.
+	buf.Reset()
+	require.NoError(t, Write(buf, FormatGitLab, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
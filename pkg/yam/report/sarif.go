@@ -0,0 +1,200 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"unicode/utf16"
+
+	"github.com/chainguard-dev/yam/pkg/diff"
+	"github.com/chainguard-dev/yam/pkg/yam"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// The types below model the subset of SARIF 2.1.0 used by yam. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+func writeSARIF(w io.Writer, results []yam.LintResult) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	var ruleIDs []string
+
+	for _, r := range results {
+		location := sarifArtifactLocation{URI: r.Path}
+		lines := diff.SplitLines(r.Original)
+
+		for _, v := range r.Violations {
+			if !slices.Contains(ruleIDs, v.Setting) {
+				ruleIDs = append(ruleIDs, v.Setting)
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               v.Setting,
+					ShortDescription: sarifMessage{Text: v.Message()},
+				})
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  v.Setting,
+				Level:   "error",
+				Message: sarifMessage{Text: v.Message()},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: location,
+						Region: sarifRegion{
+							StartLine: max(v.StartLine, 1),
+							EndLine:   max(v.EndLine, 1),
+						},
+					},
+				}},
+				Fixes: []sarifFix{{
+					Description: sarifMessage{Text: "Format with yam"},
+					ArtifactChanges: []sarifArtifactChange{{
+						ArtifactLocation: location,
+						Replacements: []sarifReplacement{{
+							DeletedRegion:   deletedRegion(v, lines),
+							InsertedContent: sarifMessage{Text: v.Replacement},
+						}},
+					}},
+				}},
+			})
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// deletedRegion returns the region of the file, whose lines are given, that
+// the violation's replacement should replace. The region covers whole lines,
+// including their newlines, by ending at the start of the line after the
+// violation.
+func deletedRegion(v yam.Violation, lines []string) sarifRegion {
+	if v.Original == "" {
+		// Nothing needs to be deleted. The replacement is inserted after the
+		// violation's line.
+		line, column := positionAfterLine(lines, v.StartLine)
+		return sarifRegion{
+			StartLine:   line,
+			StartColumn: column,
+			EndLine:     line,
+			EndColumn:   column,
+		}
+	}
+
+	endLine, endColumn := positionAfterLine(lines, v.EndLine)
+	return sarifRegion{
+		StartLine:   v.StartLine,
+		StartColumn: 1,
+		EndLine:     endLine,
+		EndColumn:   endColumn,
+	}
+}
+
+// positionAfterLine returns the line and column of the position right after
+// the given line, including its newline. That's the start of the next line,
+// unless it's the file's last line, in which case it's the end of that line, so
+// that the position doesn't point past the end of the file. Columns are counted
+// in UTF-16 code units, SARIF's default.
+func positionAfterLine(lines []string, line int) (int, int) {
+	if line < len(lines) {
+		return line + 1, 1
+	}
+	if len(lines) == 0 {
+		return 1, 1
+	}
+
+	last := lines[len(lines)-1]
+	return len(lines), len(utf16.Encode([]rune(last))) + 1
+}
@@ -0,0 +1,194 @@
+package yam
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/yam/pkg/diff"
+)
+
+// Names of the settings that a Violation can be attributed to. These match the
+// names used for the settings in a yam config file.
+const (
+	SettingIndent       = "indent"
+	SettingGap          = "gap"
+	SettingSort         = "sort"
+	SettingQuote        = "quote"
+	SettingDedup        = "dedup"
+	SettingFinalNewline = "final-newline"
+	SettingTrimLines    = "trim-lines"
+
+	// SettingFormatting is used for violations that can't be attributed to a
+	// more specific setting.
+	SettingFormatting = "formatting"
+)
+
+var settingDescriptions = map[string]string{
+	SettingIndent:       "indentation doesn't match the configured indent",
+	SettingGap:          "empty lines don't match the configured gaps",
+	SettingSort:         "items aren't sorted",
+	SettingQuote:        "quoting doesn't match the configured quotes",
+	SettingDedup:        "items aren't deduplicated",
+	SettingFinalNewline: "file doesn't end with a newline",
+	SettingTrimLines:    "lines have trailing whitespace",
+	SettingFormatting:   "content doesn't match the expected formatting",
+}
+
+// Violation describes a part of a file that isn't formatted as expected.
+type Violation struct {
+	// StartLine and EndLine are the 1-based numbers of the first and last lines
+	// of the file that should be replaced. When lines are missing from the file,
+	// rather than needing to be replaced, Original is empty, and both are the
+	// number of the line after which the missing lines should be inserted (or 0,
+	// if they should be inserted at the start of the file).
+	StartLine, EndLine int
+
+	// Setting is the name of the setting that the violation is attributed to,
+	// e.g. SettingGap.
+	Setting string
+
+	// Original is the text of the lines that should be replaced.
+	Original string
+
+	// Replacement is the text the original lines should be replaced with.
+	Replacement string
+}
+
+// Message returns a human-readable description of the violation.
+func (v Violation) Message() string {
+	return settingDescriptions[v.Setting]
+}
+
+// findViolations compares a file's original content with its formatted
+// content, and returns a Violation for each group of differing lines.
+//
+// Changes separated by a single unchanged line are grouped together, since
+// that's how a pair of swapped lines (e.g. from sorting) shows up in a diff.
+func findViolations(original, formatted []byte) []Violation {
+	var violations []Violation
+	formattedLines := diff.SplitLines(formatted)
+
+	for _, h := range diff.Hunks(original, formatted, 1) {
+		// Drop the hunk's surrounding context, keeping the unchanged lines
+		// between its changes.
+		lines := h.Lines
+		start := h.OldStart
+		if h.OldLines == 0 {
+			// The hunk is only insertions, so OldStart is the line before them.
+			start++
+		}
+		for len(lines) > 0 && lines[0].Kind == diff.Equal {
+			lines = lines[1:]
+			start++
+		}
+		for len(lines) > 0 && lines[len(lines)-1].Kind == diff.Equal {
+			lines = lines[:len(lines)-1]
+		}
+
+		var deleted, inserted []string
+		for _, l := range lines {
+			if l.Kind != diff.Insert {
+				deleted = append(deleted, l.Text)
+			}
+			if l.Kind != diff.Delete {
+				inserted = append(inserted, l.Text)
+			}
+		}
+
+		v := Violation{
+			StartLine:   start,
+			EndLine:     start + len(deleted) - 1,
+			Setting:     classifyChange(deleted, inserted, formattedLines),
+			Original:    strings.Join(deleted, ""),
+			Replacement: strings.Join(inserted, ""),
+		}
+		if len(deleted) == 0 {
+			v.StartLine = start - 1
+			v.EndLine = v.StartLine
+		}
+
+		violations = append(violations, v)
+	}
+
+	return violations
+}
+
+// classifyChange makes a best guess at which setting is responsible for the
+// given lines being replaced, given all the lines of the formatted file.
+func classifyChange(deleted, inserted, formattedLines []string) string {
+	switch {
+	case len(deleted) == len(inserted) && len(deleted) > 0 &&
+		!strings.HasSuffix(deleted[len(deleted)-1], "\n") &&
+		equalLines(deleted, inserted, func(s string) string { return strings.TrimSuffix(s, "\n") }):
+		return SettingFinalNewline
+
+	case len(deleted) == len(inserted) &&
+		equalLines(deleted, inserted, func(s string) string { return strings.TrimRight(s, " \t\n") }):
+		return SettingTrimLines
+
+	case allEmpty(deleted) && allEmpty(inserted):
+		return SettingGap
+
+	case len(deleted) == len(inserted) &&
+		equalLines(deleted, inserted, func(s string) string { return strings.TrimLeft(s, " ") }):
+		return SettingIndent
+
+	case len(deleted) == len(inserted) && isReordering(deleted, inserted):
+		return SettingSort
+
+	case len(inserted) == 0 && isSubset(deleted, formattedLines):
+		// The removed lines are still in the file, just fewer times.
+		return SettingDedup
+
+	case len(deleted) == len(inserted) &&
+		equalLines(deleted, inserted, func(s string) string { return strings.NewReplacer(`"`, "", `'`, "").Replace(s) }):
+		return SettingQuote
+	}
+
+	return SettingFormatting
+}
+
+func equalLines(a, b []string, normalize func(string) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if normalize(a[i]) != normalize(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func allEmpty(lines []string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isReordering reports whether b has exactly the same lines as a, but in a
+// different order.
+func isReordering(a, b []string) bool {
+	sortedA, sortedB := slices.Clone(a), slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+
+	return slices.Equal(sortedA, sortedB) && !slices.Equal(a, b)
+}
+
+// isSubset reports whether every line in sub is also in lines.
+func isSubset(sub, lines []string) bool {
+	for _, l := range sub {
+		if !slices.Contains(lines, l) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package yam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_findViolations(t *testing.T) {
+	cases := []struct {
+		name      string
+		original  string
+		formatted string
+		expected  []Violation
+	}{
+		{
+			name:      "no changes",
+			original:  "a: 1\n",
+			formatted: "a: 1\n",
+			expected:  nil,
+		},
+		{
+			name:      "indentation",
+			original:  "a:\n    b: 1\nc: 2\n",
+			formatted: "a:\n  b: 1\nc: 2\n",
+			expected: []Violation{
+				{StartLine: 2, EndLine: 2, Setting: SettingIndent, Original: "    b: 1\n", Replacement: "  b: 1\n"},
+			},
+		},
+		{
+			name:      "missing gap",
+			original:  "a: 1\nb: 2\n",
+			formatted: "a: 1\n\nb: 2\n",
+			expected: []Violation{
+				{StartLine: 1, EndLine: 1, Setting: SettingGap, Original: "", Replacement: "\n"},
+			},
+		},
+		{
+			name:      "missing lines at the start",
+			original:  "b: 2\n",
+			formatted: "# comment\nb: 2\n",
+			expected: []Violation{
+				{StartLine: 0, EndLine: 0, Setting: SettingFormatting, Original: "", Replacement: "# comment\n"},
+			},
+		},
+		{
+			name:      "empty file",
+			original:  "",
+			formatted: "a: 1\n",
+			expected: []Violation{
+				{StartLine: 0, EndLine: 0, Setting: SettingFormatting, Original: "", Replacement: "a: 1\n"},
+			},
+		},
+		{
+			name:      "unsorted",
+			original:  "l:\n  - b\n  - a\n",
+			formatted: "l:\n  - a\n  - b\n",
+			expected: []Violation{
+				{StartLine: 2, EndLine: 3, Setting: SettingSort, Original: "  - b\n  - a\n", Replacement: "  - a\n  - b\n"},
+			},
+		},
+		{
+			name:      "duplicates",
+			original:  "l:\n  - a\n  - a\n",
+			formatted: "l:\n  - a\n",
+			expected: []Violation{
+				{StartLine: 3, EndLine: 3, Setting: SettingDedup, Original: "  - a\n", Replacement: ""},
+			},
+		},
+		{
+			name:      "unquoted",
+			original:  "a: b\n",
+			formatted: "a: \"b\"\n",
+			expected: []Violation{
+				{StartLine: 1, EndLine: 1, Setting: SettingQuote, Original: "a: b\n", Replacement: "a: \"b\"\n"},
+			},
+		},
+		{
+			name:      "missing final newline",
+			original:  "a: 1",
+			formatted: "a: 1\n",
+			expected: []Violation{
+				{StartLine: 1, EndLine: 1, Setting: SettingFinalNewline, Original: "a: 1", Replacement: "a: 1\n"},
+			},
+		},
+		{
+			name:      "trailing whitespace",
+			original:  "a: 1  \n",
+			formatted: "a: 1\n",
+			expected: []Violation{
+				{StartLine: 1, EndLine: 1, Setting: SettingTrimLines, Original: "a: 1  \n", Replacement: "a: 1\n"},
+			},
+		},
+		{
+			name:      "other changes",
+			original:  "a:    1\n",
+			formatted: "a: 1\n",
+			expected: []Violation{
+				{StartLine: 1, EndLine: 1, Setting: SettingFormatting, Original: "a:    1\n", Replacement: "a: 1\n"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := findViolations([]byte(tt.original), []byte(tt.formatted))
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}